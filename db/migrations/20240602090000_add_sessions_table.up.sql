@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS sessions(
+    id CHAR(26) PRIMARY KEY,
+    username VARCHAR(30) NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    refresh_token_hash CHAR(64) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (username) REFERENCES users(username) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_username ON sessions(username);
+
+CREATE INDEX IF NOT EXISTS idx_session_revoked_at ON sessions(revoked_at) WHERE revoked_at IS NOT NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_merchant_username_admin;
+
+DROP TABLE IF EXISTS merchant_members;
+
+ALTER TABLE users DROP COLUMN IF EXISTS super_admin;
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_merchant_active_geohash;
+
+ALTER TABLE order_items
+    DROP CONSTRAINT IF EXISTS order_items_item_id_fkey,
+    ADD CONSTRAINT order_items_item_id_fkey FOREIGN KEY (item_id) REFERENCES products(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+ALTER TABLE order_items
+    DROP CONSTRAINT IF EXISTS order_items_merchant_id_fkey,
+    ADD CONSTRAINT order_items_merchant_id_fkey FOREIGN KEY (merchant_id) REFERENCES merchants(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+ALTER TABLE products DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE merchants DROP COLUMN IF EXISTS deleted_at;
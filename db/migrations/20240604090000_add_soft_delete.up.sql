@@ -0,0 +1,14 @@
+ALTER TABLE merchants ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- order history must never disappear when catalog is deleted
+ALTER TABLE order_items
+    DROP CONSTRAINT IF EXISTS order_items_merchant_id_fkey,
+    ADD CONSTRAINT order_items_merchant_id_fkey FOREIGN KEY (merchant_id) REFERENCES merchants(id) ON UPDATE CASCADE ON DELETE RESTRICT;
+
+ALTER TABLE order_items
+    DROP CONSTRAINT IF EXISTS order_items_item_id_fkey,
+    ADD CONSTRAINT order_items_item_id_fkey FOREIGN KEY (item_id) REFERENCES products(id) ON UPDATE CASCADE ON DELETE RESTRICT;
+
+CREATE INDEX IF NOT EXISTS idx_merchant_active_geohash ON merchants(geohash) WHERE deleted_at IS NULL;
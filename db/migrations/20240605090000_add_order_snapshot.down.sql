@@ -0,0 +1,12 @@
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS unit_price,
+    DROP COLUMN IF EXISTS product_name,
+    DROP COLUMN IF EXISTS product_category,
+    DROP COLUMN IF EXISTS product_image_url,
+    DROP COLUMN IF EXISTS merchant_name,
+    DROP COLUMN IF EXISTS merchant_category,
+    DROP COLUMN IF EXISTS merchant_image_url;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS total_price,
+    DROP COLUMN IF EXISTS estimated_delivery_time;
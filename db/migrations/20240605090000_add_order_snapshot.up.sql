@@ -0,0 +1,37 @@
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS total_price INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS estimated_delivery_time INT NOT NULL DEFAULT 0;
+
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS unit_price INT,
+    ADD COLUMN IF NOT EXISTS product_name VARCHAR(30),
+    ADD COLUMN IF NOT EXISTS product_category VARCHAR(10),
+    ADD COLUMN IF NOT EXISTS product_image_url TEXT,
+    ADD COLUMN IF NOT EXISTS merchant_name VARCHAR(30),
+    ADD COLUMN IF NOT EXISTS merchant_category VARCHAR(21),
+    ADD COLUMN IF NOT EXISTS merchant_image_url TEXT;
+
+-- best effort backfill for orders placed before snapshot exists
+UPDATE order_items oi SET
+    unit_price = p.price,
+    product_name = p.name,
+    product_category = p.category,
+    product_image_url = p.image_url,
+    merchant_name = m.name,
+    merchant_category = m.category,
+    merchant_image_url = m.image_url
+FROM products p, merchants m
+WHERE p.id = oi.item_id AND m.id = oi.merchant_id AND oi.unit_price IS NULL;
+
+UPDATE orders o SET total_price = (
+    SELECT COALESCE(SUM(oi.unit_price * oi.quantity), 0) FROM order_items oi WHERE oi.order_id = o.id
+);
+
+ALTER TABLE order_items
+    ALTER COLUMN unit_price SET NOT NULL,
+    ALTER COLUMN product_name SET NOT NULL,
+    ALTER COLUMN product_category SET NOT NULL,
+    ALTER COLUMN product_image_url SET NOT NULL,
+    ALTER COLUMN merchant_name SET NOT NULL,
+    ALTER COLUMN merchant_category SET NOT NULL,
+    ALTER COLUMN merchant_image_url SET NOT NULL;
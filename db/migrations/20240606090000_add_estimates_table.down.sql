@@ -0,0 +1 @@
+DROP TABLE IF EXISTS estimates;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS estimates(
+    id CHAR(26) PRIMARY KEY,
+    username VARCHAR(30) NOT NULL,
+    total_price INT NOT NULL,
+    data JSONB NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (username) REFERENCES users(username) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_estimate_expires_at ON estimates(expires_at);
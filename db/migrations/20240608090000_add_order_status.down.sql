@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS order_status_history;
+
+DROP INDEX IF EXISTS idx_order_username_created_at;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,22 @@
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'placed',
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_order_username_created_at ON orders(username, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS order_status_history(
+    id BIGSERIAL PRIMARY KEY,
+    order_id CHAR(26) NOT NULL,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    actor VARCHAR(30) NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order_id ON order_status_history(order_id, created_at);
+
+INSERT INTO order_status_history(order_id, to_status, actor, created_at)
+SELECT id, 'placed', username, created_at FROM orders;
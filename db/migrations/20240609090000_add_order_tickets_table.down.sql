@@ -0,0 +1 @@
+DROP TABLE IF EXISTS order_tickets;
\ No newline at end of file
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS order_tickets(
+    order_id CHAR(26) NOT NULL,
+    merchant_id CHAR(26) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    PRIMARY KEY (order_id, merchant_id),
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY (merchant_id) REFERENCES merchants(id) ON UPDATE CASCADE ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_ticket_merchant_created_at ON order_tickets(merchant_id, created_at DESC);
+
+INSERT INTO order_tickets(order_id, merchant_id, created_at)
+SELECT DISTINCT oi.order_id, oi.merchant_id, o.created_at
+FROM order_items oi JOIN orders o ON o.id = oi.order_id
+ON CONFLICT DO NOTHING;
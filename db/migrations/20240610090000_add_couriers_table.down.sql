@@ -0,0 +1,19 @@
+DROP INDEX IF EXISTS idx_order_unassigned;
+
+DROP INDEX IF EXISTS idx_order_courier_username;
+
+ALTER TABLE orders
+    DROP CONSTRAINT IF EXISTS fk_order_courier,
+    DROP CONSTRAINT IF EXISTS fk_order_starting_merchant,
+    DROP COLUMN IF EXISTS courier_assigned_at,
+    DROP COLUMN IF EXISTS courier_username,
+    DROP COLUMN IF EXISTS user_long,
+    DROP COLUMN IF EXISTS user_lat,
+    DROP COLUMN IF EXISTS starting_merchant_id;
+
+DROP TABLE IF EXISTS courier_locations;
+
+DROP TABLE IF EXISTS couriers;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS courier;
@@ -0,0 +1,44 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS courier BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS couriers(
+    username VARCHAR(30) PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'offline',
+    lat DOUBLE PRECISION,
+    long DOUBLE PRECISION,
+    geohash VARCHAR(12),
+    location_updated_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (username) REFERENCES users(username) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_courier_status_geohash ON couriers(status, geohash);
+
+CREATE TABLE IF NOT EXISTS courier_locations(
+    id BIGSERIAL PRIMARY KEY,
+    username VARCHAR(30) NOT NULL,
+    lat DOUBLE PRECISION NOT NULL,
+    long DOUBLE PRECISION NOT NULL,
+    geohash VARCHAR(12) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (username) REFERENCES couriers(username) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_courier_location_username_created_at ON courier_locations(username, created_at DESC);
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS starting_merchant_id CHAR(26),
+    ADD COLUMN IF NOT EXISTS user_lat DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS user_long DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS courier_username VARCHAR(30),
+    ADD COLUMN IF NOT EXISTS courier_assigned_at TIMESTAMPTZ,
+    ADD CONSTRAINT fk_order_starting_merchant FOREIGN KEY (starting_merchant_id) REFERENCES merchants(id) ON UPDATE CASCADE ON DELETE RESTRICT,
+    ADD CONSTRAINT fk_order_courier FOREIGN KEY (courier_username) REFERENCES couriers(username) ON UPDATE CASCADE ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_order_courier_username ON orders(courier_username) WHERE courier_username IS NOT NULL;
+
+-- orders waiting for a courier, scanned by the assignment retry
+CREATE INDEX IF NOT EXISTS idx_order_unassigned ON orders(created_at) WHERE courier_username IS NULL AND status IN ('accepted', 'preparing');
@@ -0,0 +1,11 @@
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS pricing_category,
+    DROP COLUMN IF EXISTS distance_km,
+    DROP COLUMN IF EXISTS tax,
+    DROP COLUMN IF EXISTS service_fee,
+    DROP COLUMN IF EXISTS small_order_fee,
+    DROP COLUMN IF EXISTS delivery_fee,
+    DROP COLUMN IF EXISTS discount,
+    DROP COLUMN IF EXISTS subtotal;
+
+DROP TABLE IF EXISTS pricing_rules;
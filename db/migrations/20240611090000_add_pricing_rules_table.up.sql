@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS pricing_rules(
+    category VARCHAR(30) PRIMARY KEY,
+    base_fee INT NOT NULL DEFAULT 0,
+    per_km_fee INT NOT NULL DEFAULT 0,
+    extra_merchant_fee INT NOT NULL DEFAULT 0,
+    minimum_order INT NOT NULL DEFAULT 0,
+    small_order_threshold INT NOT NULL DEFAULT 0,
+    small_order_fee INT NOT NULL DEFAULT 0,
+    free_delivery_threshold INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS subtotal INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS discount INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS delivery_fee INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS small_order_fee INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS service_fee INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS distance_km DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS pricing_category VARCHAR(30) NOT NULL DEFAULT '';
+
+-- orders placed before fees exist only paid for the items
+UPDATE orders SET subtotal = total_price;
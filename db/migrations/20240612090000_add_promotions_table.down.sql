@@ -0,0 +1,6 @@
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS promo_code;
+
+DROP TABLE IF EXISTS promotion_redemptions;
+
+DROP TABLE IF EXISTS promotions;
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS promotions(
+    code VARCHAR(30) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    discount_type VARCHAR(10) NOT NULL,
+    discount_value INT NOT NULL,
+    max_discount INT NOT NULL DEFAULT 0,
+    merchant_id CHAR(26),
+    merchant_category VARCHAR(30),
+    min_spend INT NOT NULL DEFAULT 0,
+    usage_limit INT NOT NULL DEFAULT 0,
+    usage_limit_per_user INT NOT NULL DEFAULT 0,
+    used_count INT NOT NULL DEFAULT 0,
+    starts_at TIMESTAMPTZ,
+    ends_at TIMESTAMPTZ,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by VARCHAR(30) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (merchant_id) REFERENCES merchants(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS promotion_redemptions(
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(30) NOT NULL,
+    username VARCHAR(30) NOT NULL,
+    order_id CHAR(26) NOT NULL UNIQUE,
+    discount INT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (code) REFERENCES promotions(code) ON UPDATE CASCADE ON DELETE RESTRICT,
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotion_redemption_code_username ON promotion_redemptions(code, username);
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS promo_code VARCHAR(30);
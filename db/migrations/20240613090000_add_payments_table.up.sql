@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS payments(
+    id CHAR(26) PRIMARY KEY,
+    order_id CHAR(26) NOT NULL UNIQUE,
+    provider VARCHAR(20) NOT NULL,
+    reference VARCHAR(100) NOT NULL,
+    method VARCHAR(50) NOT NULL DEFAULT '',
+    amount INT NOT NULL,
+    captured_amount INT NOT NULL DEFAULT 0,
+    refunded_amount INT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    UNIQUE (provider, reference),
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON UPDATE CASCADE ON DELETE RESTRICT
+);
+
+-- payments waiting to be captured or released, scanned by the settlement job
+CREATE INDEX IF NOT EXISTS idx_payment_authorized ON payments(created_at) WHERE status = 'authorized';
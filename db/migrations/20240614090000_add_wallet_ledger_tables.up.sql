@@ -0,0 +1,28 @@
+-- every movement of money is a transaction whose entries sum to zero,
+-- balance of an account (e.g. wallet:<username>) is the sum of its entries
+CREATE TABLE IF NOT EXISTS ledger_transactions(
+    id CHAR(26) PRIMARY KEY,
+    type VARCHAR(20) NOT NULL,
+    order_id CHAR(26),
+    reference VARCHAR(100),
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_by VARCHAR(30) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON UPDATE CASCADE ON DELETE RESTRICT
+);
+
+-- an order is charged from wallet at most once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_transaction_order ON ledger_transactions(order_id) WHERE type = 'order';
+
+CREATE TABLE IF NOT EXISTS ledger_entries(
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id CHAR(26) NOT NULL,
+    account VARCHAR(100) NOT NULL,
+    amount BIGINT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (transaction_id) REFERENCES ledger_transactions(id) ON UPDATE CASCADE ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entry_account ON ledger_entries(account, created_at DESC);
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS refunds(
+    id CHAR(26) PRIMARY KEY,
+    order_id CHAR(26) NOT NULL,
+    amount INT NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    destination VARCHAR(20) NOT NULL,
+    -- refund through payment provider stay pending until the provider confirm it
+    status VARCHAR(20) NOT NULL DEFAULT 'completed',
+    created_by VARCHAR(30) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON UPDATE CASCADE ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_refund_order ON refunds(order_id);
+
+-- refunded items, priced from the order snapshot
+CREATE TABLE IF NOT EXISTS refund_items(
+    id BIGSERIAL PRIMARY KEY,
+    refund_id CHAR(26) NOT NULL,
+    merchant_id CHAR(26) NOT NULL,
+    item_id CHAR(26) NOT NULL,
+    product_name VARCHAR(30) NOT NULL,
+    unit_price INT NOT NULL,
+    quantity INT NOT NULL,
+    amount INT NOT NULL,
+
+    FOREIGN KEY (refund_id) REFERENCES refunds(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_refund_item_refund ON refund_items(refund_id);
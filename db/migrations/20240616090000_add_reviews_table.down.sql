@@ -0,0 +1,5 @@
+ALTER TABLE merchants
+    DROP COLUMN IF EXISTS rating_sum,
+    DROP COLUMN IF EXISTS rating_count;
+
+DROP TABLE IF EXISTS reviews;
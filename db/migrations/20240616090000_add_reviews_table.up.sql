@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS reviews(
+    id CHAR(26) PRIMARY KEY,
+    order_id CHAR(26) NOT NULL,
+    merchant_id CHAR(26) NOT NULL,
+    username VARCHAR(30) NOT NULL,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    comment TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    UNIQUE (order_id, merchant_id),
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY (merchant_id) REFERENCES merchants(id) ON UPDATE CASCADE ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_merchant ON reviews(merchant_id, created_at DESC);
+
+-- aggregate kept on merchants, so nearby search can filter and sort by rating without joining reviews.
+-- sum is stored instead of average, so rounding doesn't add up as reviews come in
+ALTER TABLE merchants
+    ADD COLUMN IF NOT EXISTS rating_sum INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS rating_count INT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE orders
+    ALTER COLUMN username TYPE CHAR(26);
@@ -0,0 +1,3 @@
+-- CHAR(26) pad username with blanks, so it never equal to username read from users or token
+ALTER TABLE orders
+    ALTER COLUMN username TYPE VARCHAR(30) USING RTRIM(username);
@@ -0,0 +1,3 @@
+-- reserved_at is renewed when a stale in-flight reservation is taken over by a retry
+ALTER TABLE idempotency_keys
+    ADD COLUMN IF NOT EXISTS reserved_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_payment_pending;
+DELETE FROM payments WHERE reference IS NULL OR order_id NOT IN (SELECT id FROM orders);
+ALTER TABLE payments
+    ALTER COLUMN reference SET NOT NULL;
+ALTER TABLE payments
+    DROP COLUMN IF EXISTS username;
+ALTER TABLE payments
+    ADD CONSTRAINT payments_order_id_fkey FOREIGN KEY (order_id) REFERENCES orders(id) ON UPDATE CASCADE ON DELETE RESTRICT;
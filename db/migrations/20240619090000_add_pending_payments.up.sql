@@ -0,0 +1,12 @@
+-- payment is saved as pending before the provider is called, so it exists before its order
+-- and has no reference until the provider answer
+ALTER TABLE payments
+    DROP CONSTRAINT IF EXISTS payments_order_id_fkey;
+ALTER TABLE payments
+    ALTER COLUMN reference DROP NOT NULL;
+-- the provider is asked again for the same authorization when a pending payment is released
+ALTER TABLE payments
+    ADD COLUMN IF NOT EXISTS username VARCHAR(30) NOT NULL DEFAULT '';
+
+-- payments whose order may never be saved, scanned by the settlement job
+CREATE INDEX IF NOT EXISTS idx_payment_pending ON payments(created_at) WHERE status = 'pending';
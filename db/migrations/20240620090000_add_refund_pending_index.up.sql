@@ -0,0 +1,2 @@
+-- refunds whose provider answer was lost, retried by the refund job
+CREATE INDEX IF NOT EXISTS idx_refund_pending ON refunds(created_at) WHERE status = 'pending';
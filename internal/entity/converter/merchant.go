@@ -6,3 +6,8 @@ type MerchantResponse struct {
 	Data *[]entity.Merchant `json:"data"`
 	Meta *Meta              `json:"meta"`
 }
+
+type MerchantTicketResponse struct {
+	Data *[]entity.MerchantTicket `json:"data"`
+	Meta *Meta                    `json:"meta"`
+}
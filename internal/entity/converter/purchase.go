@@ -8,10 +8,12 @@ type MerchanNearby struct {
 }
 
 type MerchanNearbyParams struct {
-	Coordinate string `param:"coordinate"`
-	MerchantId string `query:"merchantId"`
-	Name       string `query:"name"`
-	Category   string `query:"merchantCategory"`
+	Coordinate string  `param:"coordinate"`
+	MerchantId string  `query:"merchantId"`
+	Name       string  `query:"name"`
+	Category   string  `query:"merchantCategory"`
+	MinRating  float64 `query:"minRating"`
+	SortBy     string  `query:"sortBy"`
 
 	Limit  uint `query:"limit"`
 	Offset uint `query:"offset"`
@@ -0,0 +1,43 @@
+package entity
+
+import "time"
+
+type CourierStatus string
+
+const (
+	CourierOffline CourierStatus = "offline"
+	CourierOnline  CourierStatus = "online"
+	CourierBusy    CourierStatus = "busy"
+)
+
+type Courier struct {
+	Username          string        `json:"username"`
+	Status            CourierStatus `json:"status"`
+	Location          *Coordinate   `json:"location,omitempty"`
+	LocationUpdatedAt *time.Time    `json:"locationUpdatedAt,omitempty"`
+}
+
+type CourierStatusPayload struct {
+	Status CourierStatus `json:"status" validate:"required,oneof=online offline"`
+}
+
+type CourierLocationPayload struct {
+	Lat  float64 `json:"lat" validate:"required,latitude"`
+	Long float64 `json:"long" validate:"required,longitude"`
+}
+
+// CourierLocation is sent to order subscribers while the courier is handling the order
+type CourierLocation struct {
+	Username string  `json:"username"`
+	Lat      float64 `json:"lat"`
+	Long     float64 `json:"long"`
+}
+
+// CourierOrder is an order assigned to a courier, with where to pick it up and where to deliver it
+type CourierOrder struct {
+	OrderId    string      `json:"orderId"`
+	Status     OrderStatus `json:"status"`
+	Pickup     *Coordinate `json:"pickup"`
+	Dropoff    *Coordinate `json:"dropoff"`
+	AssignedAt *time.Time  `json:"assignedAt"`
+}
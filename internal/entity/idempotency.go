@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+type IdempotencyKey struct {
+	Username     string
+	Key          string
+	RequestHash  string
+	StatusCode   *int
+	ResponseBody []byte
+	CreatedAt    *time.Time
+}
@@ -16,6 +16,10 @@ type Merchant struct {
 	Location  *Coordinate `json:"location"`
 	Geohash   string      `json:"-"`
 	CreatedAt *time.Time  `json:"createdAt"`
+
+	// only filled on nearby search
+	Rating      *float64 `json:"rating,omitempty"`
+	RatingCount *int     `json:"ratingCount,omitempty"`
 }
 
 type AddMerchantPayload struct {
@@ -25,6 +29,14 @@ type AddMerchantPayload struct {
 	Location *Coordinate `json:"location" validate:"required"`
 }
 
+// UpdateMerchantPayload only update field that is sent
+type UpdateMerchantPayload struct {
+	Name     *string     `json:"name" validate:"omitempty,min=2,max=30"`
+	Category *string     `json:"merchantCategory" validate:"omitempty,oneof=SmallRestaurant MediumRestaurant LargeRestaurant MerchandiseRestaurant BoothKiosk ConvenienceStore"`
+	ImageUrl *string     `json:"imageUrl" validate:"omitempty,imageUrl"`
+	Location *Coordinate `json:"location" validate:"omitempty"`
+}
+
 type MerchantParams struct {
 	Limit      uint   `query:"limit"`
 	Offset     uint   `query:"offset"`
@@ -33,3 +45,7 @@ type MerchantParams struct {
 	Category   string `query:"merchantCategory"`
 	CreatedAt  string `query:"createdAt"`
 }
+
+type AddMemberPayload struct {
+	Username string `json:"username" validate:"required,min=5,max=30"`
+}
@@ -1,8 +1,11 @@
 package entity
 
+import "time"
+
 type OrderPayload struct {
 	UserLocation Coordinate `json:"userLocation" validate:"required"`
 	Orders       []Order    `json:"orders" validate:"required,dive"`
+	PromoCode    string     `json:"promoCode" validate:"omitempty,max=30"`
 }
 
 type Order struct {
@@ -16,19 +19,126 @@ type Item struct {
 	Quantity uint   `json:"quantity" validate:"required,min=1"`
 }
 
+// EstimateResponse is a receipt of the estimate, totalPrice = subtotal - discount + deliveryFee + smallOrderFee + serviceFee + tax
 type EstimateResponse struct {
-	TotalPrice                     int    `json:"totalPrice"`
-	EstimatedDeliveryTimeInMinutes int    `json:"estimatedDeliveryTimeInMinutes"`
-	CalculatedEstimateId           string `json:"calculatedEstimateId"`
+	CalculatedEstimateId           string             `json:"calculatedEstimateId"`
+	PromoCode                      string             `json:"promoCode,omitempty"`
+	Subtotal                       int                `json:"subtotal"`
+	Discount                       int                `json:"discount"`
+	DeliveryFee                    int                `json:"deliveryFee"`
+	SmallOrderFee                  int                `json:"smallOrderFee"`
+	ServiceFee                     int                `json:"serviceFee"`
+	Tax                            int                `json:"tax"`
+	TotalPrice                     int                `json:"totalPrice"`
+	EstimatedDeliveryTimeInMinutes int                `json:"estimatedDeliveryTimeInMinutes"`
+	DistanceKm                     float64            `json:"distanceKm"`
+	Route                          []string           `json:"route"`
+	Merchants                      []EstimateMerchant `json:"merchants"`
+	Legs                           []RouteLeg         `json:"legs"`
+}
+
+type EstimateMerchant struct {
+	MerchantId   string         `json:"merchantId"`
+	MerchantName string         `json:"merchantName"`
+	Subtotal     int            `json:"subtotal"`
+	Items        []EstimateItem `json:"items"`
+}
+
+// RouteLeg is a part of the delivery route, From and To are merchant id or "user".
+// EtaInMinutes is the time since the courier leave the first point.
+type RouteLeg struct {
+	From              string  `json:"from"`
+	To                string  `json:"to"`
+	DistanceKm        float64 `json:"distanceKm"`
+	DurationInMinutes float64 `json:"durationInMinutes"`
+	EtaInMinutes      float64 `json:"etaInMinutes"`
+}
+
+// Estimate is calculated order waiting to be placed, item price and name are snapshot at estimate time
+type Estimate struct {
+	Id                    string         `json:"id"`
+	Username              string         `json:"username"`
+	PromoCode             string         `json:"promoCode"`
+	Subtotal              int            `json:"subtotal"`
+	Discount              int            `json:"discount"`
+	DeliveryFee           int            `json:"deliveryFee"`
+	SmallOrderFee         int            `json:"smallOrderFee"`
+	ServiceFee            int            `json:"serviceFee"`
+	Tax                   int            `json:"tax"`
+	TotalPrice            int            `json:"totalPrice"`
+	EstimatedDeliveryTime int            `json:"estimatedDeliveryTime"`
+	DistanceKm            float64        `json:"distanceKm"`
+	PricingCategory       string         `json:"pricingCategory"`
+	StartingMerchantId    string         `json:"startingMerchantId"`
+	Route                 []string       `json:"route"`
+	Legs                  []RouteLeg     `json:"legs"`
+	UserLocation          Coordinate     `json:"userLocation"`
+	Items                 []EstimateItem `json:"items"`
+	ExpiresAt             time.Time      `json:"expiresAt"`
+}
+
+type EstimateItem struct {
+	MerchantId       string `json:"merchantId"`
+	MerchantName     string `json:"merchantName"`
+	MerchantCategory string `json:"merchantCategory"`
+	MerchantImageUrl string `json:"merchantImageUrl"`
+	ProductId        string `json:"productId"`
+	ProductName      string `json:"productName"`
+	ProductCategory  string `json:"productCategory"`
+	ProductImageUrl  string `json:"productImageUrl"`
+	Price            int    `json:"price"`
+	Quantity         int    `json:"quantity"`
+}
+
+// Merchants group items of the estimate by merchant, in the order merchants appear in items
+func (e *Estimate) Merchants() []EstimateMerchant {
+	merchants := []EstimateMerchant{}
+	index := map[string]int{}
+
+	for _, item := range e.Items {
+		i, ok := index[item.MerchantId]
+		if !ok {
+			i = len(merchants)
+			index[item.MerchantId] = i
+			merchants = append(merchants, EstimateMerchant{
+				MerchantId:   item.MerchantId,
+				MerchantName: item.MerchantName,
+				Items:        []EstimateItem{},
+			})
+		}
+
+		merchants[i].Subtotal += item.Price * item.Quantity
+		merchants[i].Items = append(merchants[i].Items, item)
+	}
+
+	return merchants
+}
+
+type PostOrderPayload struct {
+	CalculatedEstimateId string `json:"calculatedEstimateId" validate:"required"`
+	PaymentMethod        string `json:"paymentMethod" validate:"omitempty,max=50"`
 }
 
 type OrderResponse struct {
 	OrderId string `json:"orderId"`
 }
 
+type CancelOrderPayload struct {
+	Reason string `json:"reason" validate:"required,min=1,max=255"`
+}
+
 type OrderHistory struct {
-	OrderId string        `json:"orderId"`
-	Orders  []OrderDetail `json:"orders"`
+	OrderId                        string               `json:"orderId"`
+	Status                         OrderStatus          `json:"status"`
+	TotalPrice                     int                  `json:"totalPrice"`
+	RefundedAmount                 int                  `json:"refundedAmount"`
+	EstimatedDeliveryTimeInMinutes int                  `json:"estimatedDeliveryTimeInMinutes"`
+	CreatedAt                      *time.Time           `json:"createdAt"`
+	Orders                         []OrderDetail        `json:"orders"`
+	Charges                        *OrderCharges        `json:"charges,omitempty"`
+	Payment                        *Payment             `json:"payment,omitempty"`
+	Refunds                        []Refund             `json:"refunds,omitempty"`
+	StatusHistory                  []OrderStatusHistory `json:"statusHistory,omitempty"`
 }
 
 type ItemHistory struct {
@@ -47,5 +157,6 @@ type OrderHistoryParams struct {
 	MerchantId       string `json:"-" query:"merchantId"`
 	MerchantCategory string `json:"-" query:"merchantCategory"`
 	Name             string `json:"-" query:"name"`
+	OrderId          string `json:"-" param:"orderId"`
 	Username         string
 }
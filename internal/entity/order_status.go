@@ -0,0 +1,46 @@
+package entity
+
+import "time"
+
+type OrderStatus string
+
+const (
+	OrderPlaced    OrderStatus = "placed"
+	OrderAccepted  OrderStatus = "accepted"
+	OrderPreparing OrderStatus = "preparing"
+	OrderPickedUp  OrderStatus = "picked_up"
+	OrderDelivered OrderStatus = "delivered"
+	OrderCancelled OrderStatus = "cancelled"
+	OrderRejected  OrderStatus = "rejected"
+)
+
+// orderTransitions list every status that can be reached from a status
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderPlaced:    {OrderAccepted, OrderCancelled, OrderRejected},
+	OrderAccepted:  {OrderPreparing, OrderCancelled},
+	OrderPreparing: {OrderPickedUp, OrderCancelled},
+	OrderPickedUp:  {OrderDelivered},
+}
+
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, status := range orderTransitions[s] {
+		if status == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsFinal report whether no transition is allowed anymore
+func (s OrderStatus) IsFinal() bool {
+	return len(orderTransitions[s]) == 0
+}
+
+type OrderStatusHistory struct {
+	FromStatus *OrderStatus `json:"fromStatus"`
+	ToStatus   OrderStatus  `json:"toStatus"`
+	Actor      string       `json:"actor"`
+	Reason     string       `json:"reason,omitempty"`
+	CreatedAt  time.Time    `json:"createdAt"`
+}
@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+type PaymentStatus string
+
+const (
+	// PaymentPending is saved before the provider is asked to authorize
+	PaymentPending    PaymentStatus = "pending"
+	PaymentAuthorized PaymentStatus = "authorized"
+	PaymentCaptured   PaymentStatus = "captured"
+	PaymentRefunded   PaymentStatus = "refunded"
+	PaymentFailed     PaymentStatus = "failed"
+	// PaymentReleased is authorization given back because its order was never saved
+	PaymentReleased PaymentStatus = "released"
+)
+
+type Payment struct {
+	Id             string        `json:"paymentId"`
+	OrderId        string        `json:"orderId"`
+	Username       string        `json:"-"`
+	Provider       string        `json:"provider"`
+	Reference      string        `json:"reference"`
+	Method         string        `json:"method"`
+	Amount         int           `json:"amount"`
+	CapturedAmount int           `json:"capturedAmount"`
+	RefundedAmount int           `json:"refundedAmount"`
+	Status         PaymentStatus `json:"status"`
+	CreatedAt      *time.Time    `json:"createdAt,omitempty"`
+	UpdatedAt      *time.Time    `json:"updatedAt,omitempty"`
+}
@@ -0,0 +1,34 @@
+package entity
+
+import "time"
+
+// DefaultPricingCategory is the rule of merchant categories without their own rule
+const DefaultPricingCategory = "default"
+
+// PricingRule decide delivery fee of an estimate from the category of its starting point merchant.
+// A threshold of 0 turn the rule off.
+type PricingRule struct {
+	Category              string     `json:"merchantCategory" param:"category" validate:"required,oneof=default SmallRestaurant MediumRestaurant LargeRestaurant MerchandiseRestaurant BoothKiosk ConvenienceStore"`
+	BaseFee               int        `json:"baseFee" validate:"min=0"`
+	PerKmFee              int        `json:"perKmFee" validate:"min=0"`
+	ExtraMerchantFee      int        `json:"extraMerchantFee" validate:"min=0"`
+	MinimumOrder          int        `json:"minimumOrder" validate:"min=0"`
+	SmallOrderThreshold   int        `json:"smallOrderThreshold" validate:"min=0"`
+	SmallOrderFee         int        `json:"smallOrderFee" validate:"min=0"`
+	FreeDeliveryThreshold int        `json:"freeDeliveryThreshold" validate:"min=0"`
+	UpdatedAt             *time.Time `json:"updatedAt,omitempty"`
+}
+
+// OrderCharges is the price breakdown saved with the order
+type OrderCharges struct {
+	Subtotal        int     `json:"subtotal"`
+	Discount        int     `json:"discount"`
+	DeliveryFee     int     `json:"deliveryFee"`
+	SmallOrderFee   int     `json:"smallOrderFee"`
+	ServiceFee      int     `json:"serviceFee"`
+	Tax             int     `json:"tax"`
+	TotalPrice      int     `json:"totalPrice"`
+	DistanceKm      float64 `json:"distanceKm"`
+	PricingCategory string  `json:"pricingCategory"`
+	PromoCode       string  `json:"promoCode,omitempty"`
+}
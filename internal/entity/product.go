@@ -19,6 +19,14 @@ type AddProductPayload struct {
 	ImageUrl string `json:"imageUrl" validate:"required,imageUrl"`
 }
 
+// UpdateProductPayload only update field that is sent
+type UpdateProductPayload struct {
+	Name     *string `json:"name" validate:"omitempty,min=2,max=30"`
+	Category *string `json:"productCategory" validate:"omitempty,oneof=Beverage Food Snack Condiments Additions"`
+	Price    *uint   `json:"price" validate:"omitempty,min=1"`
+	ImageUrl *string `json:"imageUrl" validate:"omitempty,imageUrl"`
+}
+
 type ProductResponse struct {
 	Id string `json:"itemId"`
 }
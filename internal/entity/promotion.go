@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+type DiscountType string
+
+const (
+	DiscountPercentage DiscountType = "percentage"
+	DiscountFlat       DiscountType = "flat"
+)
+
+// Promotion give discount to estimates using its code. Scope is the whole order, a merchant or a merchant category.
+// A limit of 0 means unlimited.
+type Promotion struct {
+	Code              string       `json:"code" validate:"required,min=3,max=30,alphanum"`
+	Description       string       `json:"description" validate:"max=255"`
+	DiscountType      DiscountType `json:"discountType" validate:"required,oneof=percentage flat"`
+	DiscountValue     int          `json:"discountValue" validate:"required,min=1"`
+	MaxDiscount       int          `json:"maxDiscount" validate:"min=0"`
+	MerchantId        *string      `json:"merchantId,omitempty"`
+	MerchantCategory  *string      `json:"merchantCategory,omitempty" validate:"omitempty,oneof=SmallRestaurant MediumRestaurant LargeRestaurant MerchandiseRestaurant BoothKiosk ConvenienceStore"`
+	MinSpend          int          `json:"minSpend" validate:"min=0"`
+	UsageLimit        int          `json:"usageLimit" validate:"min=0"`
+	UsageLimitPerUser int          `json:"usageLimitPerUser" validate:"min=0"`
+	UsedCount         int          `json:"usedCount"`
+	StartsAt          *time.Time   `json:"startsAt,omitempty"`
+	EndsAt            *time.Time   `json:"endsAt,omitempty"`
+	Active            bool         `json:"active"`
+	CreatedAt         *time.Time   `json:"createdAt,omitempty"`
+}
+
+type PromotionParams struct {
+	Limit  int   `query:"limit"`
+	Offset int   `query:"offset"`
+	Active *bool `query:"active"`
+}
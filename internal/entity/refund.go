@@ -0,0 +1,49 @@
+package entity
+
+import "time"
+
+const (
+	RefundToWallet  = "wallet"
+	RefundToPayment = "payment"
+)
+
+type RefundStatus string
+
+const (
+	RefundPending   RefundStatus = "pending"
+	RefundCompleted RefundStatus = "completed"
+	RefundFailed    RefundStatus = "failed"
+)
+
+type RefundItemPayload struct {
+	MerchantId string `json:"merchantId" validate:"required"`
+	ItemId     string `json:"itemId" validate:"required"`
+	Quantity   int    `json:"quantity" validate:"required,min=1"`
+}
+
+// RefundPayload refund the given items, or the whole remaining amount of the order when items are empty
+type RefundPayload struct {
+	Reason string              `json:"reason" validate:"required,min=1,max=255"`
+	Items  []RefundItemPayload `json:"items" validate:"omitempty,dive"`
+}
+
+type RefundItem struct {
+	MerchantId  string `json:"merchantId"`
+	ItemId      string `json:"itemId"`
+	ProductName string `json:"productName"`
+	UnitPrice   int    `json:"unitPrice"`
+	Quantity    int    `json:"quantity"`
+	Amount      int    `json:"amount"`
+}
+
+type Refund struct {
+	Id          string       `json:"refundId"`
+	OrderId     string       `json:"orderId"`
+	Amount      int          `json:"amount"`
+	Reason      string       `json:"reason"`
+	Destination string       `json:"destination"`
+	Status      RefundStatus `json:"status"`
+	Items       []RefundItem `json:"items"`
+	CreatedBy   string       `json:"createdBy"`
+	CreatedAt   *time.Time   `json:"createdAt,omitempty"`
+}
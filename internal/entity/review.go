@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+type ReviewPayload struct {
+	MerchantId string `json:"merchantId" validate:"required"`
+	Rating     int    `json:"rating" validate:"required,min=1,max=5"`
+	Comment    string `json:"comment" validate:"max=500"`
+}
+
+type Review struct {
+	Id         string     `json:"reviewId"`
+	OrderId    string     `json:"orderId"`
+	MerchantId string     `json:"merchantId"`
+	Rating     int        `json:"rating"`
+	Comment    string     `json:"comment"`
+	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+}
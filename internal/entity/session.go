@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+type Session struct {
+	Id               string
+	Username         string
+	Role             string
+	RefreshTokenHash string
+	ExpiresAt        time.Time
+	RevokedAt        *time.Time
+	CreatedAt        *time.Time
+}
+
+type RefreshPayload struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
@@ -0,0 +1,71 @@
+package entity
+
+import "time"
+
+// TicketStatus is status of the part of an order handled by one merchant
+type TicketStatus string
+
+const (
+	TicketPending   TicketStatus = "pending"
+	TicketAccepted  TicketStatus = "accepted"
+	TicketReady     TicketStatus = "ready"
+	TicketRejected  TicketStatus = "rejected"
+	TicketCancelled TicketStatus = "cancelled"
+)
+
+var ticketTransitions = map[TicketStatus][]TicketStatus{
+	TicketPending:  {TicketAccepted, TicketRejected, TicketCancelled},
+	TicketAccepted: {TicketReady, TicketCancelled},
+	TicketReady:    {TicketCancelled},
+}
+
+func (s TicketStatus) CanTransitionTo(next TicketStatus) bool {
+	for _, status := range ticketTransitions[s] {
+		if status == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+type MerchantTicket struct {
+	OrderId     string       `json:"orderId"`
+	MerchantId  string       `json:"merchantId"`
+	Username    string       `json:"username"`
+	Status      TicketStatus `json:"status"`
+	OrderStatus OrderStatus  `json:"orderStatus"`
+	Reason      string       `json:"reason,omitempty"`
+	Subtotal    int          `json:"subtotal"`
+	Items       []TicketItem `json:"items"`
+	CreatedAt   *time.Time   `json:"createdAt"`
+	UpdatedAt   *time.Time   `json:"updatedAt"`
+}
+
+// TicketUpdate is sent to order subscribers when a merchant works on its ticket
+type TicketUpdate struct {
+	MerchantId string       `json:"merchantId"`
+	Status     TicketStatus `json:"status"`
+	Reason     string       `json:"reason,omitempty"`
+}
+
+type TicketItem struct {
+	ItemId   string `json:"itemId"`
+	Name     string `json:"name"`
+	Category string `json:"productCategory"`
+	Price    int    `json:"price"`
+	Quantity int    `json:"quantity"`
+}
+
+type MerchantTicketParams struct {
+	Limit      uint   `query:"limit"`
+	Offset     uint   `query:"offset"`
+	MerchantId string `param:"merchantId"`
+	Status     string `query:"status"`
+	From       string `query:"from"`
+	To         string `query:"to"`
+}
+
+type RejectTicketPayload struct {
+	Reason string `json:"reason" validate:"max=255"`
+}
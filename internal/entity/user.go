@@ -1,10 +1,11 @@
 package entity
 
 type User struct {
-	Username string `json:"username" validate:"min=5,max=30"`
-	Password string `json:"password" validate:"min=5,max=30"`
-	Email    string `json:"email" validate:"email"`
-	IsAdmin  bool   `json:"-"`
+	Username   string `json:"username" validate:"min=5,max=30"`
+	Password   string `json:"password" validate:"min=5,max=30"`
+	Email      string `json:"email" validate:"email"`
+	IsAdmin    bool   `json:"-"`
+	SuperAdmin bool   `json:"-"`
 }
 
 type UserLogin struct {
@@ -13,5 +14,6 @@ type UserLogin struct {
 }
 
 type UserResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken,omitempty"`
 }
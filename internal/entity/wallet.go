@@ -0,0 +1,74 @@
+package entity
+
+import "time"
+
+type LedgerType string
+
+const (
+	LedgerTopUp  LedgerType = "top_up"
+	LedgerCredit LedgerType = "credit"
+	LedgerOrder  LedgerType = "order"
+	LedgerRefund LedgerType = "refund"
+)
+
+// PaymentMethodWallet pay the order from user wallet instead of payment provider
+const PaymentMethodWallet = "wallet"
+
+// Ledger accounts which are not owned by a user
+const (
+	AccountOrders = "system:orders"
+	AccountCredit = "system:credit"
+)
+
+func WalletAccount(username string) string {
+	return "wallet:" + username
+}
+
+func PaymentAccount(provider string) string {
+	return "payment:" + provider
+}
+
+type LedgerEntry struct {
+	Account string
+	Amount  int
+}
+
+type LedgerTransaction struct {
+	Id          string
+	Type        LedgerType
+	OrderId     *string
+	Reference   *string
+	Description string
+	CreatedBy   string
+	Entries     []LedgerEntry
+}
+
+// WalletTransaction is a ledger transaction seen from a wallet, amount is negative when money leave the wallet
+type WalletTransaction struct {
+	Id          string     `json:"transactionId"`
+	Type        LedgerType `json:"type"`
+	Amount      int        `json:"amount"`
+	OrderId     *string    `json:"orderId,omitempty"`
+	Description string     `json:"description"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+type Wallet struct {
+	Balance      int                 `json:"balance"`
+	Transactions []WalletTransaction `json:"transactions"`
+}
+
+type WalletParams struct {
+	Limit  int `query:"limit"`
+	Offset int `query:"offset"`
+}
+
+type TopUpPayload struct {
+	Amount        int    `json:"amount" validate:"required,min=1"`
+	PaymentMethod string `json:"paymentMethod" validate:"omitempty,max=50"`
+}
+
+type CreditPayload struct {
+	Amount      int    `json:"amount" validate:"required,min=1"`
+	Description string `json:"description" validate:"required,min=1,max=255"`
+}
@@ -40,9 +40,30 @@ func Unauthorized(msg string) *CustomError {
 	}
 }
 
+func Forbidden(msg string) *CustomError {
+	return &CustomError{
+		Message:    msg,
+		StatusCode: http.StatusForbidden,
+	}
+}
+
+func UnprocessableEntity(msg string) *CustomError {
+	return &CustomError{
+		Message:    msg,
+		StatusCode: http.StatusUnprocessableEntity,
+	}
+}
+
 func ServerError(msg string) *CustomError {
 	return &CustomError{
 		Message:    msg,
 		StatusCode: http.StatusInternalServerError,
 	}
 }
+
+func PaymentRequired(msg string) *CustomError {
+	return &CustomError{
+		Message:    msg,
+		StatusCode: http.StatusPaymentRequired,
+	}
+}
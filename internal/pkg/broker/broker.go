@@ -0,0 +1,43 @@
+package broker
+
+import "time"
+
+const (
+	EventStatus          = "status"
+	EventTicket          = "ticket"
+	EventCourierLocation = "courier_location"
+)
+
+type Event struct {
+	Type      string      `json:"type"`
+	OrderId   string      `json:"orderId"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+// Broker deliver events published on a topic to every subscriber of the topic
+type Broker interface {
+	Publish(topic string, event Event)
+	Subscribe(topic string) *Subscription
+}
+
+type Subscription struct {
+	Events <-chan Event
+	cancel func()
+}
+
+// Close stop receiving events, Events channel is closed afterwards
+func (s *Subscription) Close() {
+	s.cancel()
+}
+
+// New return the default broker, it only deliver events inside a single instance.
+// Implement Broker on top of a shared pub/sub when running more than one instance.
+func New() Broker {
+	return NewMemoryBroker()
+}
+
+// OrderTopic is the topic where events of an order are published
+func OrderTopic(orderId string) string {
+	return "order:" + orderId
+}
@@ -0,0 +1,67 @@
+package broker
+
+import (
+	"sync"
+	"time"
+)
+
+// subscriber buffer, events are dropped for slow subscriber when it is full
+const bufferSize = 16
+
+type memoryBroker struct {
+	mu     sync.RWMutex
+	topics map[string]map[chan Event]bool
+}
+
+// NewMemoryBroker only deliver events inside a single instance
+func NewMemoryBroker() Broker {
+	return &memoryBroker{
+		topics: make(map[string]map[chan Event]bool),
+	}
+}
+
+func (m *memoryBroker) Publish(topic string, event Event) {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for ch := range m.topics[topic] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+func (m *memoryBroker) Subscribe(topic string) *Subscription {
+	ch := make(chan Event, bufferSize)
+
+	m.mu.Lock()
+	if _, ok := m.topics[topic]; !ok {
+		m.topics[topic] = make(map[chan Event]bool)
+	}
+	m.topics[topic][ch] = true
+	m.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			m.mu.Lock()
+			delete(m.topics[topic], ch)
+			if len(m.topics[topic]) == 0 {
+				delete(m.topics, topic)
+			}
+			m.mu.Unlock()
+
+			close(ch)
+		})
+	}
+
+	return &Subscription{
+		Events: ch,
+		cancel: cancel,
+	}
+}
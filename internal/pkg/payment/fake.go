@@ -0,0 +1,86 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+const (
+	// FakeMethodDeclined make the fake provider decline authorization
+	FakeMethodDeclined = "fake_declined"
+
+	// FakeSignatureHeader carry hex HMAC-SHA256 of the webhook body
+	FakeSignatureHeader = "X-Fake-Signature"
+)
+
+type fakeProvider struct {
+	secret []byte
+}
+
+// NewFakeProvider never move real money and always answer the same for the same input, for local development and tests
+func NewFakeProvider(secret string) Provider {
+	return &fakeProvider{
+		secret: []byte(secret),
+	}
+}
+
+func (f *fakeProvider) Name() string {
+	return "fake"
+}
+
+func (f *fakeProvider) Authorize(ctx context.Context, req *AuthorizeRequest) (*Result, error) {
+	if req.Method == FakeMethodDeclined || req.Amount < 0 {
+		return nil, ErrDeclined
+	}
+
+	return &Result{
+		Reference: "fake_" + req.OrderId,
+		Status:    StatusAuthorized,
+		Amount:    req.Amount,
+	}, nil
+}
+
+func (f *fakeProvider) Capture(ctx context.Context, reference string, amount int) (*Result, error) {
+	return &Result{
+		Reference: reference,
+		Status:    StatusCaptured,
+		Amount:    amount,
+	}, nil
+}
+
+func (f *fakeProvider) Refund(ctx context.Context, reference string, amount int, key string) (*Result, error) {
+	return &Result{
+		Reference: reference,
+		Status:    StatusRefunded,
+		Amount:    amount,
+	}, nil
+}
+
+func (f *fakeProvider) VerifyWebhook(header http.Header, body []byte) (*WebhookEvent, error) {
+	if len(f.secret) == 0 {
+		return nil, errors.New("webhook secret is not configured")
+	}
+
+	signature, err := hex.DecodeString(header.Get(FakeSignatureHeader))
+	if err != nil {
+		return nil, errors.New("invalid signature")
+	}
+
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(body)
+	if hmac.Equal(signature, mac.Sum(nil)) == false {
+		return nil, errors.New("invalid signature")
+	}
+
+	event := &WebhookEvent{}
+	if err := json.Unmarshal(body, event); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
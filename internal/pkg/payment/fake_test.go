@@ -0,0 +1,84 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestFakeAuthorize(t *testing.T) {
+	provider := NewFakeProvider("")
+
+	tests := []struct {
+		name   string
+		method string
+		amount int
+		want   error
+	}{
+		{"authorized", "card", 30000, nil},
+		{"declined method", FakeMethodDeclined, 30000, ErrDeclined},
+		{"negative amount", "card", -1, ErrDeclined},
+	}
+
+	for _, tt := range tests {
+		result, err := provider.Authorize(context.Background(), &AuthorizeRequest{OrderId: "order-1", Username: "alice", Method: tt.method, Amount: tt.amount})
+		if errors.Is(err, tt.want) == false || (tt.want == nil && err != nil) {
+			t.Fatalf("%s: got error %v, want %v", tt.name, err, tt.want)
+		}
+
+		if err == nil && (result.Status != StatusAuthorized || result.Amount != tt.amount) {
+			t.Errorf("%s: got result %+v", tt.name, result)
+		}
+	}
+
+	// asked again for the same order, the same authorization is returned
+	first, _ := provider.Authorize(context.Background(), &AuthorizeRequest{OrderId: "order-2", Method: "card", Amount: 100})
+	second, _ := provider.Authorize(context.Background(), &AuthorizeRequest{OrderId: "order-2", Method: "card", Amount: 100})
+	if first.Reference != second.Reference {
+		t.Errorf("reference %s and %s of the same order differ", first.Reference, second.Reference)
+	}
+}
+
+func TestFakeVerifyWebhook(t *testing.T) {
+	body := []byte(`{"reference":"fake_order-1","status":"captured","amount":30000}`)
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		valid     bool
+	}{
+		{"valid signature", "secret", sign("secret", body), true},
+		{"signed by another secret", "secret", sign("other", body), false},
+		{"not hex", "secret", "not-a-signature", false},
+		{"missing signature", "secret", "", false},
+		{"secret not configured", "", sign("", body), false},
+	}
+
+	for _, tt := range tests {
+		header := http.Header{}
+		if tt.signature != "" {
+			header.Set(FakeSignatureHeader, tt.signature)
+		}
+
+		event, err := NewFakeProvider(tt.secret).VerifyWebhook(header, body)
+		if (err == nil) != tt.valid {
+			t.Fatalf("%s: got error %v, want valid %v", tt.name, err, tt.valid)
+		}
+
+		if tt.valid && (event.Reference != "fake_order-1" || event.Status != StatusCaptured || event.Amount != 30000) {
+			t.Errorf("%s: got event %+v", tt.name, event)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+)
+
+type Status string
+
+const (
+	StatusAuthorized Status = "authorized"
+	StatusCaptured   Status = "captured"
+	StatusRefunded   Status = "refunded"
+	StatusFailed     Status = "failed"
+)
+
+// ErrDeclined is returned by Authorize when the provider refuse the payment
+var ErrDeclined = errors.New("payment declined")
+
+type AuthorizeRequest struct {
+	OrderId  string
+	Username string
+	Method   string
+	Amount   int
+}
+
+type Result struct {
+	Reference string
+	Status    Status
+	Amount    int
+}
+
+// WebhookEvent is a payment change reported by the provider
+type WebhookEvent struct {
+	Reference string `json:"reference"`
+	Status    Status `json:"status"`
+	Amount    int    `json:"amount"`
+}
+
+// Provider hold money of the user until the order is delivered, then capture it or give it back.
+// Authorize must return the same authorization when it is asked again for the same OrderId,
+// it is how a payment whose answer was lost is found to be released.
+// Refund is done once per key, sending it again with the same key return the first result,
+// so a refund whose answer was lost can be sent again.
+type Provider interface {
+	Name() string
+	Authorize(ctx context.Context, req *AuthorizeRequest) (*Result, error)
+	Capture(ctx context.Context, reference string, amount int) (*Result, error)
+	Refund(ctx context.Context, reference string, amount int, key string) (*Result, error)
+	VerifyWebhook(header http.Header, body []byte) (*WebhookEvent, error)
+}
+
+// New return provider chosen by PAYMENT_PROVIDER env, only fake is available for now
+func New() (Provider, error) {
+	switch os.Getenv("PAYMENT_PROVIDER") {
+	case "", "fake":
+		return NewFakeProvider(os.Getenv("PAYMENT_WEBHOOK_SECRET")), nil
+	default:
+		return nil, errors.New("unknown PAYMENT_PROVIDER " + os.Getenv("PAYMENT_PROVIDER"))
+	}
+}
@@ -0,0 +1,161 @@
+package routeplan
+
+import (
+	"math"
+
+	"github.com/malikfajr/beli-mang/internal/pkg/routing"
+)
+
+// ExactLimit is the most stops solved exactly, Held-Karp grows with 2^n * n^2
+const ExactLimit = 10
+
+// Plan find the fastest path which leave start, visit every other point once and finish at end.
+// It return the visited points in order, without start and end, and the total duration in minutes.
+func Plan(matrix [][]routing.Leg, start int, end int) ([]int, float64) {
+	stops := []int{}
+	for i := range matrix {
+		if i != start && i != end {
+			stops = append(stops, i)
+		}
+	}
+
+	var order []int
+	if len(stops) <= ExactLimit {
+		order = heldKarp(matrix, start, end, stops)
+	} else {
+		order = twoOpt(matrix, start, end, nearestNeighbour(matrix, start, stops))
+	}
+
+	return order, Duration(matrix, start, end, order)
+}
+
+// Duration of the path start -> order... -> end
+func Duration(matrix [][]routing.Leg, start int, end int, order []int) float64 {
+	total := 0.0
+	current := start
+	for _, stop := range order {
+		total += matrix[current][stop].DurationMin
+		current = stop
+	}
+
+	return total + matrix[current][end].DurationMin
+}
+
+// heldKarp solve the path exactly with dynamic programming over subsets of stops
+func heldKarp(matrix [][]routing.Leg, start int, end int, stops []int) []int {
+	n := len(stops)
+	if n == 0 {
+		return []int{}
+	}
+
+	full := 1<<n - 1
+
+	// cost[mask][last] is the fastest path from start visiting stops in mask and ending at stops[last]
+	cost := make([][]float64, full+1)
+	parent := make([][]int, full+1)
+	for mask := range cost {
+		cost[mask] = make([]float64, n)
+		parent[mask] = make([]int, n)
+		for last := range cost[mask] {
+			cost[mask][last] = math.Inf(1)
+			parent[mask][last] = -1
+		}
+	}
+
+	for i, stop := range stops {
+		cost[1<<i][i] = matrix[start][stop].DurationMin
+	}
+
+	for mask := 1; mask <= full; mask++ {
+		for last := 0; last < n; last++ {
+			if mask&(1<<last) == 0 || math.IsInf(cost[mask][last], 1) {
+				continue
+			}
+
+			for next := 0; next < n; next++ {
+				if mask&(1<<next) != 0 {
+					continue
+				}
+
+				nextMask := mask | 1<<next
+				candidate := cost[mask][last] + matrix[stops[last]][stops[next]].DurationMin
+				if candidate < cost[nextMask][next] {
+					cost[nextMask][next] = candidate
+					parent[nextMask][next] = last
+				}
+			}
+		}
+	}
+
+	best, bestCost := 0, math.Inf(1)
+	for last := 0; last < n; last++ {
+		candidate := cost[full][last] + matrix[stops[last]][end].DurationMin
+		if candidate < bestCost {
+			best, bestCost = last, candidate
+		}
+	}
+
+	order := make([]int, n)
+	mask := full
+	for i := n - 1; i >= 0; i-- {
+		order[i] = stops[best]
+		previous := parent[mask][best]
+		mask &^= 1 << best
+		best = previous
+	}
+
+	return order
+}
+
+// nearestNeighbour always go to the closest stop not visited yet
+func nearestNeighbour(matrix [][]routing.Leg, start int, stops []int) []int {
+	order := make([]int, 0, len(stops))
+	visited := make(map[int]bool, len(stops))
+	current := start
+
+	for len(order) < len(stops) {
+		nearest, minDuration := -1, math.Inf(1)
+		for _, stop := range stops {
+			if !visited[stop] && matrix[current][stop].DurationMin < minDuration {
+				nearest, minDuration = stop, matrix[current][stop].DurationMin
+			}
+		}
+
+		visited[nearest] = true
+		order = append(order, nearest)
+		current = nearest
+	}
+
+	return order
+}
+
+// twoOpt keep reversing a segment of the path while it make the path faster.
+// Legs may be asymmetric, so the whole path is measured instead of only the swapped edges.
+func twoOpt(matrix [][]routing.Leg, start int, end int, order []int) []int {
+	best := Duration(matrix, start, end, order)
+
+	for improved := true; improved; {
+		improved = false
+		for i := 0; i < len(order)-1; i++ {
+			for j := i + 1; j < len(order); j++ {
+				reverse(order, i, j)
+
+				if duration := Duration(matrix, start, end, order); duration < best-1e-9 {
+					best = duration
+					improved = true
+					continue
+				}
+
+				reverse(order, i, j)
+			}
+		}
+	}
+
+	return order
+}
+
+func reverse(order []int, i int, j int) {
+	for ; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+}
@@ -0,0 +1,150 @@
+package routeplan
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/malikfajr/beli-mang/internal/pkg/routing"
+)
+
+// randomMatrix build asymmetric legs, travel from i to j differ from j to i
+func randomMatrix(r *rand.Rand, n int) [][]routing.Leg {
+	matrix := make([][]routing.Leg, n)
+	for i := range matrix {
+		matrix[i] = make([]routing.Leg, n)
+		for j := range matrix[i] {
+			if i != j {
+				matrix[i][j] = routing.Leg{DurationMin: 1 + r.Float64()*60}
+			}
+		}
+	}
+
+	return matrix
+}
+
+// bruteForce try every order of stops
+func bruteForce(matrix [][]routing.Leg, start int, end int, stops []int) float64 {
+	best := math.Inf(1)
+	used := make([]bool, len(stops))
+
+	var visit func(current int, count int, total float64)
+	visit = func(current int, count int, total float64) {
+		if total >= best {
+			return
+		}
+
+		if count == len(stops) {
+			best = min(best, total+matrix[current][end].DurationMin)
+			return
+		}
+
+		for i, stop := range stops {
+			if used[i] {
+				continue
+			}
+			used[i] = true
+			visit(stop, count+1, total+matrix[current][stop].DurationMin)
+			used[i] = false
+		}
+	}
+	visit(start, 0, 0)
+
+	return best
+}
+
+func stopsOf(n int, start int, end int) []int {
+	stops := []int{}
+	for i := 0; i < n; i++ {
+		if i != start && i != end {
+			stops = append(stops, i)
+		}
+	}
+
+	return stops
+}
+
+func assertVisitAll(t *testing.T, order []int, stops []int) {
+	t.Helper()
+
+	sorted := slices.Clone(order)
+	slices.Sort(sorted)
+	if slices.Equal(sorted, stops) == false {
+		t.Fatalf("order %v doesn't visit every stop %v exactly once", order, stops)
+	}
+}
+
+func TestHeldKarpMatchBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(18))
+
+	for stopCount := 0; stopCount <= ExactLimit; stopCount++ {
+		runs := 20
+		if stopCount >= 8 {
+			runs = 2
+		}
+
+		for run := 0; run < runs; run++ {
+			// the user is both start and end, as in delivery estimate, or a different point
+			n, start, end := stopCount+1, 0, 0
+			if run%2 == 1 {
+				n, end = stopCount+2, stopCount+1
+			}
+
+			matrix := randomMatrix(r, n)
+			stops := stopsOf(n, start, end)
+
+			order := heldKarp(matrix, start, end, stops)
+			assertVisitAll(t, order, stops)
+
+			got := Duration(matrix, start, end, order)
+			want := bruteForce(matrix, start, end, stops)
+			if math.Abs(got-want) > 1e-9 {
+				t.Fatalf("%d stops: heldKarp %v take %f, brute force %f", stopCount, order, got, want)
+			}
+
+			if _, duration := Plan(matrix, start, end); math.Abs(duration-want) > 1e-9 {
+				t.Fatalf("%d stops: Plan take %f, brute force %f", stopCount, duration, want)
+			}
+		}
+	}
+}
+
+func TestTwoOptNeverWorseThanNearestNeighbour(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+
+	for run := 0; run < 200; run++ {
+		n := 3 + r.Intn(25)
+		start, end := 0, 0
+		if run%2 == 1 {
+			end = n - 1
+		}
+
+		matrix := randomMatrix(r, n)
+		stops := stopsOf(n, start, end)
+
+		greedy := nearestNeighbour(matrix, start, stops)
+		assertVisitAll(t, greedy, stops)
+
+		improved := twoOpt(matrix, start, end, slices.Clone(greedy))
+		assertVisitAll(t, improved, stops)
+
+		if Duration(matrix, start, end, improved) > Duration(matrix, start, end, greedy)+1e-9 {
+			t.Fatalf("run %d: twoOpt %f is worse than nearest neighbour %f", run,
+				Duration(matrix, start, end, improved), Duration(matrix, start, end, greedy))
+		}
+	}
+}
+
+func TestPlanAboveExactLimit(t *testing.T) {
+	r := rand.New(rand.NewSource(30))
+	n := ExactLimit + 6
+
+	matrix := randomMatrix(r, n)
+	order, duration := Plan(matrix, 0, 0)
+
+	assertVisitAll(t, order, stopsOf(n, 0, 0))
+	if math.Abs(duration-Duration(matrix, 0, 0, order)) > 1e-9 {
+		t.Errorf("Plan report %f, path take %f", duration, Duration(matrix, 0, 0, order))
+	}
+}
@@ -0,0 +1,48 @@
+package routing
+
+import (
+	"context"
+	"math"
+)
+
+const earthRadiusKm = 6371
+
+type haversineRouter struct {
+	speedKmPerMin float64
+}
+
+// NewHaversineRouter travel in straight line at constant speed
+func NewHaversineRouter(speedKmh float64) Router {
+	return &haversineRouter{
+		speedKmPerMin: speedKmh / 60,
+	}
+}
+
+func (h *haversineRouter) Matrix(ctx context.Context, points []Point) ([][]Leg, error) {
+	matrix := make([][]Leg, len(points))
+	for i, from := range points {
+		matrix[i] = make([]Leg, len(points))
+		for j, to := range points {
+			distance := Haversine(from, to)
+			matrix[i][j] = Leg{
+				DistanceKm:  distance,
+				DurationMin: distance / h.speedKmPerMin,
+			}
+		}
+	}
+
+	return matrix, nil
+}
+
+// Haversine return great-circle distance between two points in kilometer
+func Haversine(from Point, to Point) float64 {
+	dLat := toRadians(to.Lat - from.Lat)
+	dLon := toRadians(to.Long - from.Long)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRadians(from.Lat))*math.Cos(toRadians(to.Lat))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+	return earthRadiusKm * c
+}
+
+func toRadians(degree float64) float64 {
+	return degree * math.Pi / 180
+}
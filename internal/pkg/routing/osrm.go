@@ -0,0 +1,89 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type osrmRouter struct {
+	baseUrl string
+	client  *http.Client
+}
+
+// NewOSRMRouter ask an OSRM compatible server, e.g. http://localhost:5000, for road travel using the table service
+func NewOSRMRouter(baseUrl string) Router {
+	return &osrmRouter{
+		baseUrl: strings.TrimRight(baseUrl, "/"),
+		client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+type osrmTable struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Durations [][]*float64 `json:"durations"`
+	Distances [][]*float64 `json:"distances"`
+}
+
+func (o *osrmRouter) Matrix(ctx context.Context, points []Point) ([][]Leg, error) {
+	coordinates := make([]string, 0, len(points))
+	for _, point := range points {
+		// OSRM take longitude first
+		coordinates = append(coordinates, strconv.FormatFloat(point.Long, 'f', 6, 64)+","+strconv.FormatFloat(point.Lat, 'f', 6, 64))
+	}
+
+	url := fmt.Sprintf("%s/table/v1/driving/%s?annotations=duration,distance", o.baseUrl, strings.Join(coordinates, ";"))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := o.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	table := &osrmTable{}
+	if err := json.NewDecoder(res.Body).Decode(table); err != nil {
+		return nil, fmt.Errorf("osrm: invalid response with status %d: %w", res.StatusCode, err)
+	}
+
+	if table.Code != "Ok" {
+		return nil, errors.New("osrm: " + table.Code + " " + table.Message)
+	}
+
+	if len(table.Durations) != len(points) || len(table.Distances) != len(points) {
+		return nil, errors.New("osrm: table size doesn't match the points")
+	}
+
+	matrix := make([][]Leg, len(points))
+	for i := range points {
+		if len(table.Durations[i]) != len(points) || len(table.Distances[i]) != len(points) {
+			return nil, errors.New("osrm: table size doesn't match the points")
+		}
+
+		matrix[i] = make([]Leg, len(points))
+		for j := range points {
+			duration, distance := table.Durations[i][j], table.Distances[i][j]
+			if duration == nil || distance == nil {
+				return nil, fmt.Errorf("osrm: no route from point %d to point %d", i, j)
+			}
+
+			// OSRM answer in seconds and meters
+			matrix[i][j] = Leg{
+				DistanceKm:  *distance / 1000,
+				DurationMin: *duration / 60,
+			}
+		}
+	}
+
+	return matrix, nil
+}
@@ -0,0 +1,130 @@
+package routing
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var testPoints = []Point{
+	{Lat: -6.2, Long: 106.8},
+	{Lat: -6.3, Long: 106.9},
+}
+
+func newOSRMStub(t *testing.T, status int, body string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/table/v1/driving/106.800000,-6.200000;106.900000,-6.300000" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		if r.URL.Query().Get("annotations") != "duration,distance" {
+			t.Errorf("unexpected annotations %s", r.URL.Query().Get("annotations"))
+		}
+
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestOSRMRouterMatrix(t *testing.T) {
+	server := newOSRMStub(t, http.StatusOK, `{
+		"code": "Ok",
+		"durations": [[0, 600], [720, 0]],
+		"distances": [[0, 12000], [13500, 0]]
+	}`)
+
+	matrix, err := NewOSRMRouter(server.URL+"/").Matrix(context.Background(), testPoints)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]Leg{
+		{{0, 0}, {12, 10}},
+		{{13.5, 12}, {0, 0}},
+	}
+
+	for i := range want {
+		for j := range want[i] {
+			if matrix[i][j] != want[i][j] {
+				t.Errorf("matrix[%d][%d] = %+v, want %+v", i, j, matrix[i][j], want[i][j])
+			}
+		}
+	}
+}
+
+func TestOSRMRouterErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		message string
+	}{
+		{"code is not Ok", http.StatusBadRequest, `{"code": "InvalidQuery", "message": "Query string malformed"}`, "InvalidQuery"},
+		{"http error", http.StatusBadGateway, `<html>bad gateway</html>`, "status 502"},
+		{"no route", http.StatusOK, `{"code": "Ok", "durations": [[0, null], [720, 0]], "distances": [[0, null], [13500, 0]]}`, "no route"},
+		{"table size", http.StatusOK, `{"code": "Ok", "durations": [[0]], "distances": [[0]]}`, "table size"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newOSRMStub(t, tt.status, tt.body)
+
+			_, err := NewOSRMRouter(server.URL).Matrix(context.Background(), testPoints)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			if strings.Contains(err.Error(), tt.message) == false {
+				t.Errorf("error %q doesn't mention %q", err.Error(), tt.message)
+			}
+		})
+	}
+}
+
+func TestOSRMRouterUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	if _, err := NewOSRMRouter(server.URL).Matrix(context.Background(), testPoints); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestWithFallback(t *testing.T) {
+	haversine := NewHaversineRouter(DefaultSpeedKmh)
+
+	t.Run("primary succeeds", func(t *testing.T) {
+		server := newOSRMStub(t, http.StatusOK, `{"code": "Ok", "durations": [[0, 600], [720, 0]], "distances": [[0, 12000], [13500, 0]]}`)
+
+		matrix, err := WithFallback(NewOSRMRouter(server.URL), haversine).Matrix(context.Background(), testPoints)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if matrix[0][1] != (Leg{DistanceKm: 12, DurationMin: 10}) {
+			t.Errorf("got %+v, want leg from osrm", matrix[0][1])
+		}
+	})
+
+	t.Run("primary fails", func(t *testing.T) {
+		server := newOSRMStub(t, http.StatusInternalServerError, `{"code": "InternalError"}`)
+
+		matrix, err := WithFallback(NewOSRMRouter(server.URL), haversine).Matrix(context.Background(), testPoints)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want, _ := haversine.Matrix(context.Background(), testPoints)
+		if math.Abs(matrix[0][1].DistanceKm-want[0][1].DistanceKm) > 1e-9 || matrix[0][1].DistanceKm == 0 {
+			t.Errorf("got %+v, want haversine leg %+v", matrix[0][1], want[0][1])
+		}
+	})
+}
@@ -0,0 +1,32 @@
+package routing
+
+import "context"
+
+type roadFactorRouter struct {
+	base   Router
+	factor float64
+}
+
+// NewRoadFactorRouter stretch legs of base router, roads are rarely straight so 1.3 - 1.5 is common in a city
+func NewRoadFactorRouter(base Router, factor float64) Router {
+	return &roadFactorRouter{
+		base:   base,
+		factor: factor,
+	}
+}
+
+func (r *roadFactorRouter) Matrix(ctx context.Context, points []Point) ([][]Leg, error) {
+	matrix, err := r.base.Matrix(ctx, points)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range matrix {
+		for j := range matrix[i] {
+			matrix[i][j].DistanceKm *= r.factor
+			matrix[i][j].DurationMin *= r.factor
+		}
+	}
+
+	return matrix, nil
+}
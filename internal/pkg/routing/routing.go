@@ -0,0 +1,79 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+)
+
+// DefaultSpeedKmh is the average courier speed when the router doesn't know the road
+const DefaultSpeedKmh = 40.0
+
+type Point struct {
+	Lat  float64
+	Long float64
+}
+
+// Leg is the travel from one point to another
+type Leg struct {
+	DistanceKm  float64
+	DurationMin float64
+}
+
+// Router compute travel between every pair of points, Matrix[i][j] is the leg from points[i] to points[j]
+type Router interface {
+	Matrix(ctx context.Context, points []Point) ([][]Leg, error)
+}
+
+// New return router chosen by ROUTER env: haversine (default), road or osrm
+func New() (Router, error) {
+	speed := envFloat("ROUTER_SPEED_KMH", DefaultSpeedKmh)
+
+	switch os.Getenv("ROUTER") {
+	case "", "haversine":
+		return NewHaversineRouter(speed), nil
+	case "road":
+		return NewRoadFactorRouter(NewHaversineRouter(speed), envFloat("ROUTER_ROAD_FACTOR", 1.3)), nil
+	case "osrm":
+		if os.Getenv("OSRM_URL") == "" {
+			return nil, errors.New("OSRM_URL is required when ROUTER is osrm")
+		}
+		return WithFallback(NewOSRMRouter(os.Getenv("OSRM_URL")), NewHaversineRouter(speed)), nil
+	default:
+		return nil, errors.New("unknown ROUTER " + os.Getenv("ROUTER"))
+	}
+}
+
+type fallbackRouter struct {
+	primary  Router
+	fallback Router
+}
+
+// WithFallback use fallback router when primary router fails, so estimate is still available
+func WithFallback(primary Router, fallback Router) Router {
+	return &fallbackRouter{
+		primary:  primary,
+		fallback: fallback,
+	}
+}
+
+func (f *fallbackRouter) Matrix(ctx context.Context, points []Point) ([][]Leg, error) {
+	matrix, err := f.primary.Matrix(ctx, points)
+	if err == nil {
+		return matrix, nil
+	}
+
+	log.Println("router failed, using fallback, because: ", err.Error())
+	return f.fallback.Matrix(ctx, points)
+}
+
+func envFloat(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil || value <= 0 {
+		return fallback
+	}
+
+	return value
+}
@@ -10,26 +10,43 @@ import (
 
 type JwtClaim struct {
 	Username string `json:"username"`
-	Admin    bool   `json:"admin"`
+	Role     Role   `json:"role"`
 	jwt.RegisteredClaims
 }
 
-var secret []byte
+var (
+	// AccessTokenTTL is lifetime of access token, keep it short because revocation is checked by jti
+	AccessTokenTTL = 15 * time.Minute
+
+	// RefreshTokenTTL is lifetime of a session, every refresh will extend it
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
 
 func init() {
-	secret = []byte(os.Getenv("JWT_SECRET"))
+	if ttl, err := time.ParseDuration(os.Getenv("JWT_ACCESS_TTL")); err == nil && ttl > 0 {
+		AccessTokenTTL = ttl
+	}
+
+	if ttl, err := time.ParseDuration(os.Getenv("JWT_REFRESH_TTL")); err == nil && ttl > 0 {
+		RefreshTokenTTL = ttl
+	}
 }
 
-func CreateToken(username string, admin bool) string {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &JwtClaim{
-		Admin:    admin,
+// CreateToken create access token, sessionId is used as jti claim
+func CreateToken(sessionId string, username string, role Role) string {
+	key := signingKey()
+
+	token := jwt.NewWithClaims(key.Method, &JwtClaim{
+		Role:     role,
 		Username: username,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(8 * time.Hour)),
+			ID:        sessionId,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
 		},
 	})
+	token.Header["kid"] = key.Kid
 
-	ss, err := token.SignedString(secret)
+	ss, err := token.SignedString(key.private)
 	if err != nil {
 		panic(err)
 	}
@@ -40,18 +57,26 @@ func CreateToken(username string, admin bool) string {
 // jwt claim token
 func ClaimToken(token string) (*JwtClaim, error) {
 	parsed, err := jwt.ParseWithClaims(token, &JwtClaim{}, func(token *jwt.Token) (interface{}, error) {
-		return secret, nil
+		kid, _ := token.Header["kid"].(string)
+
+		key, ok := verificationKey(kid)
+		if ok == false {
+			return nil, errors.New("Unknown key")
+		}
+
+		// never trust alg header, it must match the key
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, errors.New("Invalid signing method")
+		}
+
+		return key.public, nil
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	if parsed.Method != jwt.SigningMethodHS256 {
-		return nil, errors.New("Invalid token")
-	}
-
-	if claim, ok := parsed.Claims.(*JwtClaim); ok {
+	if claim, ok := parsed.Claims.(*JwtClaim); ok && claim.ID != "" {
 		return claim, nil
 	} else {
 		return nil, errors.New("Invalid token")
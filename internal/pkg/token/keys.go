@@ -0,0 +1,230 @@
+package token
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is a signing or verification key identified by kid header
+type Key struct {
+	Kid    string
+	Method jwt.SigningMethod
+
+	// private is []byte for HS256, *rsa.PrivateKey for RS256 and ed25519.PrivateKey for EdDSA
+	private interface{}
+	// public is []byte for HS256, *rsa.PublicKey for RS256 and ed25519.PublicKey for EdDSA
+	public interface{}
+}
+
+type keySet struct {
+	signing *Key
+	verify  map[string]*Key
+}
+
+var (
+	keys   *keySet
+	keysMu sync.RWMutex
+)
+
+// LoadKeys read signing key and verification keys from environment.
+//
+//	JWT_ALG              HS256 (default), RS256 or EdDSA
+//	JWT_SECRET           secret for HS256, required
+//	JWT_PRIVATE_KEY_FILE PEM private key for RS256 / EdDSA, may be a symlink to the current key
+//	JWT_KID              kid of signing key, default is file name of private key after following symlink, or "default"
+//	JWT_PUBLIC_KEYS_DIR  directory of *.pem public keys still accepted, kid is the file name
+//
+// Environment of a running process can't change, so to rotate without downtime JWT_PRIVATE_KEY_FILE
+// point to a symlink. Put the new public key to JWT_PUBLIC_KEYS_DIR on every instance and reload, then
+// move the symlink to the new private key and reload again. Remove the old public key after AccessTokenTTL.
+// A kid is never given to another key, loading fail instead so tokens signed by the old key stay valid.
+func LoadKeys() error {
+	signing, err := loadSigningKey()
+	if err != nil {
+		return err
+	}
+
+	verify := map[string]*Key{
+		signing.Kid: signing,
+	}
+
+	if dir := os.Getenv("JWT_PUBLIC_KEYS_DIR"); dir != "" {
+		files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			key, err := loadPublicKey(file)
+			if err != nil {
+				return err
+			}
+
+			if exist, ok := verify[key.Kid]; ok {
+				if sameKey(exist, key) == false {
+					return fmt.Errorf("public key %s doesn't match signing key of the same kid", file)
+				}
+				continue
+			}
+
+			verify[key.Kid] = key
+		}
+	}
+
+	keysMu.Lock()
+	defer keysMu.Unlock()
+
+	if keys != nil {
+		previous := keys.signing
+		if previous.Kid == signing.Kid && sameKey(previous, signing) == false {
+			return errors.New("kid " + signing.Kid + " is already used by another key, give the new key its own file name or JWT_KID")
+		}
+
+		// tokens signed by this instance stay valid, even if the old public key is not in JWT_PUBLIC_KEYS_DIR
+		if _, ok := verify[previous.Kid]; ok == false {
+			verify[previous.Kid] = previous
+		}
+	}
+
+	keys = &keySet{
+		signing: signing,
+		verify:  verify,
+	}
+
+	return nil
+}
+
+func loadSigningKey() (*Key, error) {
+	alg := os.Getenv("JWT_ALG")
+	kid := os.Getenv("JWT_KID")
+
+	if alg == "" || alg == jwt.SigningMethodHS256.Alg() {
+		if kid == "" {
+			kid = "default"
+		}
+
+		secret := []byte(os.Getenv("JWT_SECRET"))
+		if len(secret) == 0 {
+			return nil, errors.New("JWT_SECRET is required for HS256")
+		}
+
+		return &Key{
+			Kid:     kid,
+			Method:  jwt.SigningMethodHS256,
+			private: secret,
+			public:  secret,
+		}, nil
+	}
+
+	file := os.Getenv("JWT_PRIVATE_KEY_FILE")
+	if file == "" {
+		return nil, errors.New("JWT_PRIVATE_KEY_FILE is required for " + alg)
+	}
+
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if kid == "" {
+		current, err := filepath.EvalSymlinks(file)
+		if err != nil {
+			return nil, err
+		}
+		kid = kidFromFile(current)
+	}
+
+	switch alg {
+	case jwt.SigningMethodRS256.Alg():
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Key{Kid: kid, Method: jwt.SigningMethodRS256, private: private, public: &private.PublicKey}, nil
+	case jwt.SigningMethodEdDSA.Alg():
+		private, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, err
+		}
+
+		edPrivate := private.(ed25519.PrivateKey)
+		return &Key{Kid: kid, Method: jwt.SigningMethodEdDSA, private: edPrivate, public: edPrivate.Public()}, nil
+	}
+
+	return nil, errors.New("JWT_ALG " + alg + " is not supported")
+}
+
+func loadPublicKey(file string) (*Key, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	kid := kidFromFile(file)
+
+	if public, err := jwt.ParseRSAPublicKeyFromPEM(pem); err == nil {
+		return &Key{Kid: kid, Method: jwt.SigningMethodRS256, public: public}, nil
+	}
+
+	if public, err := jwt.ParseEdPublicKeyFromPEM(pem); err == nil {
+		return &Key{Kid: kid, Method: jwt.SigningMethodEdDSA, public: public}, nil
+	}
+
+	return nil, fmt.Errorf("cannot parse public key %s", file)
+}
+
+// sameKey report whether both keys verify the same signatures
+func sameKey(a *Key, b *Key) bool {
+	if a.Method.Alg() != b.Method.Alg() {
+		return false
+	}
+
+	switch public := a.public.(type) {
+	case []byte:
+		other, ok := b.public.([]byte)
+		return ok && bytes.Equal(public, other)
+	case interface{ Equal(crypto.PublicKey) bool }:
+		return public.Equal(b.public)
+	}
+
+	return false
+}
+
+func kidFromFile(file string) string {
+	return strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+}
+
+func signingKey() *Key {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+
+	return keys.signing
+}
+
+func verificationKey(kid string) (*Key, bool) {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+
+	key, ok := keys.verify[kid]
+	return key, ok
+}
+
+func isPublishable(key *Key) bool {
+	switch key.public.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,145 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeKeyPair write name.pem private key to dir and name.pem public key to dir/public
+func writeKeyPair(t *testing.T, dir string, name string) {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, name+".pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "public", name+".pem"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// useKeyDir point environment to a fresh key directory with current.pem symlink as signing key
+func useKeyDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "public"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("JWT_ALG", "EdDSA")
+	t.Setenv("JWT_KID", "")
+	t.Setenv("JWT_PRIVATE_KEY_FILE", filepath.Join(dir, "current.pem"))
+	t.Setenv("JWT_PUBLIC_KEYS_DIR", filepath.Join(dir, "public"))
+
+	previous := keys
+	keys = nil
+	t.Cleanup(func() { keys = previous })
+
+	return dir
+}
+
+func pointCurrent(t *testing.T, dir string, name string) {
+	t.Helper()
+
+	current := filepath.Join(dir, "current.pem")
+	os.Remove(current)
+	if err := os.Symlink(name+".pem", current); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadKeysRejectEmptySecret(t *testing.T) {
+	t.Setenv("JWT_ALG", "HS256")
+	t.Setenv("JWT_SECRET", "")
+
+	if err := LoadKeys(); err == nil {
+		t.Fatal("expected error for empty JWT_SECRET")
+	}
+}
+
+func TestRotateBySymlink(t *testing.T) {
+	dir := useKeyDir(t)
+
+	writeKeyPair(t, dir, "2024-06")
+	pointCurrent(t, dir, "2024-06")
+	if err := LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	oldToken := CreateToken("session-1", "tester", RoleUser)
+
+	writeKeyPair(t, dir, "2024-07")
+	if err := LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	pointCurrent(t, dir, "2024-07")
+	if err := LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	if kid := signingKey().Kid; kid != "2024-07" {
+		t.Fatalf("signing kid = %s, want 2024-07", kid)
+	}
+
+	if _, err := ClaimToken(oldToken); err != nil {
+		t.Errorf("token of old key is rejected after rotation: %v", err)
+	}
+
+	if _, err := ClaimToken(CreateToken("session-2", "tester", RoleUser)); err != nil {
+		t.Errorf("token of new key is rejected: %v", err)
+	}
+}
+
+func TestReloadRejectKidOfAnotherKey(t *testing.T) {
+	dir := useKeyDir(t)
+
+	writeKeyPair(t, dir, "2024-06")
+	pointCurrent(t, dir, "2024-06")
+	if err := LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	oldToken := CreateToken("session-1", "tester", RoleUser)
+
+	// the private key is replaced in place, public key of the old one is still under the same name
+	public, err := os.ReadFile(filepath.Join(dir, "public", "2024-06.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeKeyPair(t, dir, "2024-06")
+	if err := os.WriteFile(filepath.Join(dir, "public", "2024-06.pem"), public, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadKeys(); err == nil {
+		t.Fatal("expected error when kid is given to another key")
+	}
+
+	if _, err := ClaimToken(oldToken); err != nil {
+		t.Errorf("token of old key is rejected after failed reload: %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// CreateRefreshToken generate opaque refresh token with format <sessionId>.<random>
+// only the hash is stored in database
+func CreateRefreshToken(sessionId string) (string, string) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+
+	refreshToken := sessionId + "." + base64.RawURLEncoding.EncodeToString(buf)
+
+	return refreshToken, HashRefreshToken(refreshToken)
+}
+
+// ParseRefreshToken return session id and hash of refresh token
+func ParseRefreshToken(refreshToken string) (string, string, error) {
+	sessionId, _, found := strings.Cut(refreshToken, ".")
+	if found == false || sessionId == "" {
+		return "", "", errors.New("Invalid refresh token")
+	}
+
+	return sessionId, HashRefreshToken(refreshToken), nil
+}
+
+func HashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,21 @@
+package token
+
+type Role string
+
+const (
+	RoleAdmin      Role = "admin"
+	RoleSuperAdmin Role = "super-admin"
+	RoleUser       Role = "user"
+	RoleCourier    Role = "courier"
+)
+
+// HasRole report whether the claim carries one of the given roles
+func (c *JwtClaim) HasRole(roles ...Role) bool {
+	for _, role := range roles {
+		if c.Role == role {
+			return true
+		}
+	}
+
+	return false
+}
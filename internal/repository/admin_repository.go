@@ -45,9 +45,9 @@ func (r *AdminRepo) InsertTx(ctx context.Context, tx pgx.Tx, user *entity.User)
 
 func (r *AdminRepo) GetByUsername(ctx context.Context, pool *pgxpool.Pool, username string) (*entity.User, error) {
 	var user = &entity.User{IsAdmin: true}
-	query := "SELECT username, password, email FROM users WHERE username = $1 AND admin = true LIMIT 1;"
+	query := "SELECT username, password, email, super_admin FROM users WHERE username = $1 AND admin = true LIMIT 1;"
 
-	err := pool.QueryRow(ctx, query, username).Scan(&user.Username, &user.Password, &user.Email)
+	err := pool.QueryRow(ctx, query, username).Scan(&user.Username, &user.Password, &user.Email, &user.SuperAdmin)
 	if err != nil {
 		return nil, errors.New("Account not found!")
 	}
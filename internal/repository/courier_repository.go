@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/mmcloughlin/geohash"
+)
+
+type CourierRepo struct{}
+
+func (r *CourierRepo) EmailExistTx(ctx context.Context, tx pgx.Tx, email string) bool {
+	var exist int
+	query := "SELECT 1 FROM users WHERE email = $1 AND courier = true LIMIT 1;"
+
+	err := tx.QueryRow(ctx, query, email).Scan(&exist)
+	if err != nil {
+		return false
+	}
+
+	return true
+}
+
+func (r *CourierRepo) InsertTx(ctx context.Context, tx pgx.Tx, user *entity.User) error {
+	query := `INSERT INTO users(admin, courier, username, password, email) VALUES(false, true, @username, @password, @email) ON CONFLICT DO NOTHING`
+	args := pgx.NamedArgs{
+		"username": user.Username,
+		"password": user.Password,
+		"email":    user.Email,
+	}
+
+	tag, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		panic(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New("Username already exists")
+	}
+
+	if _, err := tx.Exec(ctx, "INSERT INTO couriers(username) VALUES($1)", user.Username); err != nil {
+		panic(err)
+	}
+
+	return nil
+}
+
+func (r *CourierRepo) GetByUsername(ctx context.Context, pool *pgxpool.Pool, username string) (*entity.User, error) {
+	var user = &entity.User{}
+	query := "SELECT username, password, email FROM users WHERE username = $1 AND courier = true LIMIT 1;"
+
+	err := pool.QueryRow(ctx, query, username).Scan(&user.Username, &user.Password, &user.Email)
+	if err != nil {
+		return nil, errors.New("Account not found!")
+	}
+
+	return user, nil
+}
+
+// GetForUpdateTx lock the courier row until transaction end
+func (r *CourierRepo) GetForUpdateTx(ctx context.Context, tx pgx.Tx, username string) (*entity.Courier, error) {
+	courier := &entity.Courier{}
+	var lat, long *float64
+	query := "SELECT username, status, lat, long, location_updated_at FROM couriers WHERE username = $1 FOR UPDATE"
+
+	err := tx.QueryRow(ctx, query, username).Scan(&courier.Username, &courier.Status, &lat, &long, &courier.LocationUpdatedAt)
+	if err != nil {
+		return nil, errors.New("courier not found")
+	}
+
+	if lat != nil && long != nil {
+		courier.Location = &entity.Coordinate{Lat: *lat, Long: *long}
+	}
+
+	return courier, nil
+}
+
+func (r *CourierRepo) SetStatusTx(ctx context.Context, tx pgx.Tx, username string, status entity.CourierStatus) error {
+	query := "UPDATE couriers SET status = $1, updated_at = NOW() WHERE username = $2"
+
+	_, err := tx.Exec(ctx, query, status, username)
+	return err
+}
+
+// UpdateLocation save the latest position of the courier and keep the ping in location history
+func (r *CourierRepo) UpdateLocation(ctx context.Context, pool *pgxpool.Pool, username string, lat float64, long float64) error {
+	hash := geohash.Encode(lat, long)
+
+	query := `WITH updated AS (
+			UPDATE couriers SET lat = $2, long = $3, geohash = $4, location_updated_at = NOW(), updated_at = NOW()
+			WHERE username = $1
+			RETURNING username
+		)
+		INSERT INTO courier_locations(username, lat, long, geohash) SELECT username, $2, $3, $4 FROM updated`
+
+	tag, err := pool.Exec(ctx, query, username, lat, long, hash)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New("courier not found")
+	}
+
+	return nil
+}
+
+// FindNearestAvailableTx lock the closest online courier to the coordinate whose location is newer than seenAfter.
+// Couriers locked by another assignment are skipped.
+func (r *CourierRepo) FindNearestAvailableTx(ctx context.Context, tx pgx.Tx, lat float64, long float64, seenAfter time.Time) (string, error) {
+	var username string
+	hash := geohash.Encode(lat, long)
+
+	query := `SELECT username FROM couriers
+		WHERE status = @status AND geohash LIKE @geoparam AND location_updated_at > @seen_after
+		ORDER BY haversine(@lat, @long, lat, long)
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED`
+	args := pgx.NamedArgs{
+		"status":     entity.CourierOnline,
+		"geoparam":   hash[:3] + "%",
+		"seen_after": seenAfter,
+		"lat":        lat,
+		"long":       long,
+	}
+
+	if err := tx.QueryRow(ctx, query, args).Scan(&username); err != nil {
+		return "", errors.New("no courier available")
+	}
+
+	return username, nil
+}
+
+// GetActiveOrders return orders assigned to the courier which are not delivered or cancelled yet
+func (r *CourierRepo) GetActiveOrders(ctx context.Context, pool *pgxpool.Pool, username string) []entity.CourierOrder {
+	query := `SELECT o.id, o.status, m.lat, m.long, o.user_lat, o.user_long, o.courier_assigned_at
+		FROM orders o JOIN merchants m ON m.id = o.starting_merchant_id
+		WHERE o.courier_username = $1 AND o.status IN ($2, $3, $4)
+		ORDER BY o.courier_assigned_at`
+
+	rows, err := pool.Query(ctx, query, username, entity.OrderAccepted, entity.OrderPreparing, entity.OrderPickedUp)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+
+	orders := []entity.CourierOrder{}
+	for rows.Next() {
+		order := entity.CourierOrder{
+			Pickup:  &entity.Coordinate{},
+			Dropoff: &entity.Coordinate{},
+		}
+		err := rows.Scan(&order.OrderId, &order.Status, &order.Pickup.Lat, &order.Pickup.Long,
+			&order.Dropoff.Lat, &order.Dropoff.Long, &order.AssignedAt)
+		if err != nil {
+			panic(err)
+		}
+		orders = append(orders, order)
+	}
+
+	return orders
+}
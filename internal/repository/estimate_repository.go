@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+)
+
+type EstimateRepo struct{}
+
+func (e *EstimateRepo) Insert(ctx context.Context, pool *pgxpool.Pool, estimate *entity.Estimate) error {
+	data, err := json.Marshal(estimate)
+	if err != nil {
+		return err
+	}
+
+	query := "INSERT INTO estimates(id, username, total_price, data, expires_at) VALUES(@id, @username, @total_price, @data, @expires_at)"
+	args := pgx.NamedArgs{
+		"id":          estimate.Id,
+		"username":    estimate.Username,
+		"total_price": estimate.TotalPrice,
+		"data":        data,
+		"expires_at":  estimate.ExpiresAt,
+	}
+
+	_, err = pool.Exec(ctx, query, args)
+	return err
+}
+
+// GetActive return estimate of the user which is not expired. pgx.ErrNoRows is returned when nothing match.
+func (e *EstimateRepo) GetActive(ctx context.Context, pool *pgxpool.Pool, estimateId string, username string) (*entity.Estimate, error) {
+	query := "SELECT data FROM estimates WHERE id = $1 AND username = $2 AND expires_at > NOW()"
+
+	return e.scan(pool.QueryRow(ctx, query, estimateId, username))
+}
+
+// ConsumeTx delete and return active estimate of the user in one statement, so the same estimate can't be
+// ordered twice by concurrent request. pgx.ErrNoRows is returned when nothing match.
+func (e *EstimateRepo) ConsumeTx(ctx context.Context, tx pgx.Tx, estimateId string, username string) (*entity.Estimate, error) {
+	query := "DELETE FROM estimates WHERE id = $1 AND username = $2 AND expires_at > NOW() RETURNING data"
+
+	return e.scan(tx.QueryRow(ctx, query, estimateId, username))
+}
+
+// GetOwner return username who created the estimate, whether it is expired or not
+func (e *EstimateRepo) GetOwner(ctx context.Context, pool *pgxpool.Pool, estimateId string) (string, error) {
+	var username string
+	err := pool.QueryRow(ctx, "SELECT username FROM estimates WHERE id = $1", estimateId).Scan(&username)
+
+	return username, err
+}
+
+func (e *EstimateRepo) DeleteExpired(ctx context.Context, pool *pgxpool.Pool) (int64, error) {
+	tag, err := pool.Exec(ctx, "DELETE FROM estimates WHERE expires_at <= NOW()")
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func (e *EstimateRepo) scan(row pgx.Row) (*entity.Estimate, error) {
+	var data []byte
+	if err := row.Scan(&data); err != nil {
+		return nil, err
+	}
+
+	estimate := &entity.Estimate{}
+	if err := json.Unmarshal(data, estimate); err != nil {
+		return nil, err
+	}
+
+	return estimate, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+)
+
+type IdempotencyRepo struct{}
+
+// Reserve claim the key for a request in progress and return reserved_at of the claim, ok is false if the key
+// is already used. A reservation of the same request still in progress after staleAfter is taken over, because
+// the request holding it is assumed to be crashed.
+func (i *IdempotencyRepo) Reserve(ctx context.Context, pool *pgxpool.Pool, username string, key string, requestHash string, staleAfter time.Duration) (reservedAt time.Time, ok bool, err error) {
+	query := `INSERT INTO idempotency_keys(username, key, request_hash, reserved_at) VALUES($1, $2, $3, NOW())
+		ON CONFLICT (username, key) DO UPDATE SET reserved_at = NOW()
+		WHERE idempotency_keys.status_code IS NULL
+			AND idempotency_keys.request_hash = EXCLUDED.request_hash
+			AND idempotency_keys.reserved_at < NOW() - make_interval(secs => $4)
+		RETURNING reserved_at`
+
+	err = pool.QueryRow(ctx, query, username, key, requestHash, staleAfter.Seconds()).Scan(&reservedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	return reservedAt, true, nil
+}
+
+func (i *IdempotencyRepo) Get(ctx context.Context, pool *pgxpool.Pool, username string, key string) (*entity.IdempotencyKey, error) {
+	record := &entity.IdempotencyKey{}
+	query := "SELECT username, key, request_hash, status_code, response_body, created_at FROM idempotency_keys WHERE username = $1 AND key = $2"
+
+	err := pool.QueryRow(ctx, query, username, key).Scan(&record.Username, &record.Key, &record.RequestHash, &record.StatusCode, &record.ResponseBody, &record.CreatedAt)
+	if err != nil {
+		return nil, errors.New("idempotency key not found")
+	}
+
+	return record, nil
+}
+
+// Complete store the response, only when the reservation is still held since reservedAt
+func (i *IdempotencyRepo) Complete(ctx context.Context, pool *pgxpool.Pool, username string, key string, reservedAt time.Time, statusCode int, body []byte) error {
+	query := "UPDATE idempotency_keys SET status_code = $1, response_body = $2, completed_at = NOW() WHERE username = $3 AND key = $4 AND reserved_at = $5 AND status_code IS NULL"
+
+	_, err := pool.Exec(ctx, query, statusCode, body, username, key, reservedAt)
+	return err
+}
+
+// Release remove the reservation, only when it is still held since reservedAt and not completed
+func (i *IdempotencyRepo) Release(ctx context.Context, pool *pgxpool.Pool, username string, key string, reservedAt time.Time) error {
+	query := "DELETE FROM idempotency_keys WHERE username = $1 AND key = $2 AND reserved_at = $3 AND status_code IS NULL"
+
+	_, err := pool.Exec(ctx, query, username, key, reservedAt)
+	return err
+}
+
+func (i *IdempotencyRepo) DeleteOlderThan(ctx context.Context, pool *pgxpool.Pool, before time.Time) (int64, error) {
+	tag, err := pool.Exec(ctx, "DELETE FROM idempotency_keys WHERE created_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+)
+
+type LedgerRepo struct{}
+
+// LockAccountTx serialize writers of the account until transaction end, so balance read before a debit stays valid
+func (l *LedgerRepo) LockAccountTx(ctx context.Context, tx pgx.Tx, account string) error {
+	_, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", account)
+	return err
+}
+
+func (l *LedgerRepo) BalanceTx(ctx context.Context, tx pgx.Tx, account string) (int, error) {
+	var balance int
+	err := tx.QueryRow(ctx, "SELECT COALESCE(SUM(amount), 0) FROM ledger_entries WHERE account = $1", account).Scan(&balance)
+	return balance, err
+}
+
+func (l *LedgerRepo) Balance(ctx context.Context, pool *pgxpool.Pool, account string) (int, error) {
+	var balance int
+	err := pool.QueryRow(ctx, "SELECT COALESCE(SUM(amount), 0) FROM ledger_entries WHERE account = $1", account).Scan(&balance)
+	return balance, err
+}
+
+// GetChargedAccountTx return the wallet account which paid for the order
+func (l *LedgerRepo) GetChargedAccountTx(ctx context.Context, tx pgx.Tx, orderId string) (string, error) {
+	query := `SELECT e.account FROM ledger_entries e JOIN ledger_transactions t ON t.id = e.transaction_id
+		WHERE t.order_id = $1 AND t.type = $2 AND e.amount < 0
+		LIMIT 1`
+
+	var account string
+	err := tx.QueryRow(ctx, query, orderId, entity.LedgerOrder).Scan(&account)
+	return account, err
+}
+
+// InsertTx save the transaction with its entries, entries must be balanced
+func (l *LedgerRepo) InsertTx(ctx context.Context, tx pgx.Tx, transaction *entity.LedgerTransaction) error {
+	sum := 0
+	for _, entry := range transaction.Entries {
+		sum += entry.Amount
+	}
+	if len(transaction.Entries) < 2 || sum != 0 {
+		return errors.New("ledger transaction is not balanced")
+	}
+
+	query := `INSERT INTO ledger_transactions(id, type, order_id, reference, description, created_by)
+		VALUES(@id, @type, @order_id, @reference, @description, @created_by)`
+	args := pgx.NamedArgs{
+		"id":          transaction.Id,
+		"type":        transaction.Type,
+		"order_id":    transaction.OrderId,
+		"reference":   transaction.Reference,
+		"description": transaction.Description,
+		"created_by":  transaction.CreatedBy,
+	}
+
+	if _, err := tx.Exec(ctx, query, args); err != nil {
+		return err
+	}
+
+	rows := [][]interface{}{}
+	for _, entry := range transaction.Entries {
+		rows = append(rows, []interface{}{transaction.Id, entry.Account, entry.Amount})
+	}
+
+	_, err := tx.CopyFrom(ctx, pgx.Identifier{"ledger_entries"}, []string{"transaction_id", "account", "amount"}, pgx.CopyFromRows(rows))
+	return err
+}
+
+// GetAccountHistory return transactions touching the account, newest first
+func (l *LedgerRepo) GetAccountHistory(ctx context.Context, pool *pgxpool.Pool, account string, limit int, offset int) []entity.WalletTransaction {
+	query := `SELECT t.id, t.type, e.amount, t.order_id, t.description, t.created_at
+		FROM ledger_entries e JOIN ledger_transactions t ON t.id = e.transaction_id
+		WHERE e.account = $1
+		ORDER BY t.created_at DESC, t.id DESC
+		LIMIT $2 OFFSET $3`
+
+	transactions := []entity.WalletTransaction{}
+
+	rows, err := pool.Query(ctx, query, account, limit, offset)
+	if err != nil {
+		return transactions
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		transaction := entity.WalletTransaction{}
+		if err := rows.Scan(&transaction.Id, &transaction.Type, &transaction.Amount, &transaction.OrderId, &transaction.Description, &transaction.CreatedAt); err != nil {
+			return transactions
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions
+}
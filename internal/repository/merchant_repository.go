@@ -17,7 +17,23 @@ func (m *MerchantRepo) GetById(ctx context.Context, pool *pgxpool.Pool, merchant
 	merchant := &entity.Merchant{}
 	coordinate := &entity.Coordinate{}
 
-	query := "SELECT id, username_admin, name, category, image_url, lat, long, created_at  FROM merchants WHERE id = $1 LIMIT 1;"
+	query := "SELECT id, username_admin, name, category, image_url, lat, long, created_at  FROM merchants WHERE id = $1 AND deleted_at IS NULL LIMIT 1;"
+
+	err := pool.QueryRow(ctx, query, merchantId).Scan(&merchant.Id, &merchant.Username, &merchant.Name, &merchant.Category, &merchant.ImageUrl, &coordinate.Lat, &coordinate.Long, &merchant.CreatedAt)
+	merchant.Location = coordinate
+	if err != nil {
+		return nil, errors.New("merchant not found")
+	}
+
+	return merchant, nil
+}
+
+// GetDeletedById return soft deleted merchant, used to restore it
+func (m *MerchantRepo) GetDeletedById(ctx context.Context, pool *pgxpool.Pool, merchantId string) (*entity.Merchant, error) {
+	merchant := &entity.Merchant{}
+	coordinate := &entity.Coordinate{}
+
+	query := "SELECT id, username_admin, name, category, image_url, lat, long, created_at  FROM merchants WHERE id = $1 AND deleted_at IS NOT NULL LIMIT 1;"
 
 	err := pool.QueryRow(ctx, query, merchantId).Scan(&merchant.Id, &merchant.Username, &merchant.Name, &merchant.Category, &merchant.ImageUrl, &coordinate.Lat, &coordinate.Long, &merchant.CreatedAt)
 	merchant.Location = coordinate
@@ -53,11 +69,71 @@ func (m *MerchantRepo) Insert(ctx context.Context, pool *pgxpool.Pool, merchant
 	return nil
 }
 
+// Update change detail and location of a merchant which is not deleted
+func (m *MerchantRepo) Update(ctx context.Context, pool *pgxpool.Pool, merchant *entity.Merchant) error {
+	query := "UPDATE merchants SET name = @name, category = @category, image_url = @image, lat = @lat, long = @long, geohash = @geohash WHERE id = @id AND deleted_at IS NULL"
+	args := pgx.NamedArgs{
+		"id":       merchant.Id,
+		"name":     merchant.Name,
+		"category": merchant.Category,
+		"image":    merchant.ImageUrl,
+		"lat":      merchant.Location.Lat,
+		"long":     merchant.Location.Long,
+		"geohash":  geohash.Encode(merchant.Location.Lat, merchant.Location.Long),
+	}
+
+	tag, err := pool.Exec(ctx, query, args)
+	if err != nil {
+		panic(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New("merchant not found")
+	}
+
+	return nil
+}
+
+// Delete soft delete merchant, order history still refer to it
+func (m *MerchantRepo) Delete(ctx context.Context, pool *pgxpool.Pool, merchantId string) error {
+	query := "UPDATE merchants SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL"
+
+	tag, err := pool.Exec(ctx, query, merchantId)
+	if err != nil {
+		panic(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New("merchant not found")
+	}
+
+	return nil
+}
+
+func (m *MerchantRepo) Restore(ctx context.Context, pool *pgxpool.Pool, merchantId string) error {
+	query := "UPDATE merchants SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL"
+
+	tag, err := pool.Exec(ctx, query, merchantId)
+	if err != nil {
+		panic(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New("merchant not found")
+	}
+
+	return nil
+}
+
+// GetAll return merchants managed by username, empty username return every merchant
 func (m *MerchantRepo) GetAll(ctx context.Context, pool *pgxpool.Pool, username string, params *entity.MerchantParams) []entity.Merchant {
 
-	query := "SELECT id, username_admin, name, category, image_url, lat, long, geohash, created_at  FROM merchants WHERE TRUE "
-	args := pgx.NamedArgs{
-		// "username": username,
+	query := "SELECT id, username_admin, name, category, image_url, lat, long, geohash, created_at  FROM merchants WHERE deleted_at IS NULL "
+	args := pgx.NamedArgs{}
+
+	if username != "" {
+		query += " AND (username_admin = @username OR id IN (SELECT merchant_id FROM merchant_members WHERE username = @username))"
+		args["username"] = username
 	}
 
 	if params.Name != "" {
@@ -102,9 +178,12 @@ func (m *MerchantRepo) GetAll(ctx context.Context, pool *pgxpool.Pool, username
 }
 
 func (m *MerchantRepo) GetTotalMerchant(ctx context.Context, pool *pgxpool.Pool, username string, params *entity.MerchantParams) int {
-	query := "SELECT COUNT(id) FROM merchants WHERE TRUE "
-	args := pgx.NamedArgs{
-		// "username": username,
+	query := "SELECT COUNT(id) FROM merchants WHERE deleted_at IS NULL "
+	args := pgx.NamedArgs{}
+
+	if username != "" {
+		query += " AND (username_admin = @username OR id IN (SELECT merchant_id FROM merchant_members WHERE username = @username))"
+		args["username"] = username
 	}
 
 	if params.Name != "" {
@@ -126,6 +205,52 @@ func (m *MerchantRepo) GetTotalMerchant(ctx context.Context, pool *pgxpool.Pool,
 	return total
 }
 
+// CanManage check merchant is not deleted and username is its owner or member, all skip the ownership check
+func (m *MerchantRepo) CanManage(ctx context.Context, pool *pgxpool.Pool, merchantId string, username string, all bool) bool {
+	query := `SELECT EXISTS(
+		SELECT 1 FROM merchants m WHERE m.id = $1 AND m.deleted_at IS NULL AND (
+			$3 OR m.username_admin = $2 OR EXISTS(SELECT 1 FROM merchant_members mm WHERE mm.merchant_id = m.id AND mm.username = $2)
+		)
+	)`
+
+	var allowed bool
+	if err := pool.QueryRow(ctx, query, merchantId, username, all).Scan(&allowed); err != nil {
+		return false
+	}
+
+	return allowed
+}
+
+func (m *MerchantRepo) AddMember(ctx context.Context, pool *pgxpool.Pool, merchantId string, username string) error {
+	query := "INSERT INTO merchant_members(merchant_id, username) VALUES($1, $2) ON CONFLICT DO NOTHING"
+
+	tag, err := pool.Exec(ctx, query, merchantId, username)
+	if err != nil {
+		panic(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New("Member already exists")
+	}
+
+	return nil
+}
+
+func (m *MerchantRepo) DeleteMember(ctx context.Context, pool *pgxpool.Pool, merchantId string, username string) error {
+	query := "DELETE FROM merchant_members WHERE merchant_id = $1 AND username = $2"
+
+	tag, err := pool.Exec(ctx, query, merchantId, username)
+	if err != nil {
+		panic(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New("Member not found")
+	}
+
+	return nil
+}
+
 func (m *MerchantRepo) AddProduct(ctx context.Context, pool *pgxpool.Pool, product *entity.Product) error {
 	query := "INSERT INTO products(id, merchant_id, name, category, price, image_url) VALUES($1, $2, $3, $4, $5, $6)"
 
@@ -141,8 +266,66 @@ func (m *MerchantRepo) AddProduct(ctx context.Context, pool *pgxpool.Pool, produ
 	return nil
 }
 
+func (m *MerchantRepo) GetProductById(ctx context.Context, pool *pgxpool.Pool, merchantId string, productId string) (*entity.Product, error) {
+	product := &entity.Product{}
+	query := "SELECT id, merchant_id, name, category, price, image_url, created_at FROM products WHERE id = $1 AND merchant_id = $2 AND deleted_at IS NULL LIMIT 1"
+
+	err := pool.QueryRow(ctx, query, productId, merchantId).Scan(&product.Id, &product.MerchantId, &product.Name, &product.Category, &product.Price, &product.ImageUrl, &product.CreatedAt)
+	if err != nil {
+		return nil, errors.New("item not found")
+	}
+
+	return product, nil
+}
+
+func (m *MerchantRepo) UpdateProduct(ctx context.Context, pool *pgxpool.Pool, product *entity.Product) error {
+	query := "UPDATE products SET name = $1, category = $2, price = $3, image_url = $4 WHERE id = $5 AND merchant_id = $6 AND deleted_at IS NULL"
+
+	tag, err := pool.Exec(ctx, query, product.Name, product.Category, product.Price, product.ImageUrl, product.Id, product.MerchantId)
+	if err != nil {
+		panic(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New("item not found")
+	}
+
+	return nil
+}
+
+// DeleteProduct soft delete product, order history still refer to it
+func (m *MerchantRepo) DeleteProduct(ctx context.Context, pool *pgxpool.Pool, merchantId string, productId string) error {
+	query := "UPDATE products SET deleted_at = NOW() WHERE id = $1 AND merchant_id = $2 AND deleted_at IS NULL"
+
+	tag, err := pool.Exec(ctx, query, productId, merchantId)
+	if err != nil {
+		panic(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New("item not found")
+	}
+
+	return nil
+}
+
+func (m *MerchantRepo) RestoreProduct(ctx context.Context, pool *pgxpool.Pool, merchantId string, productId string) error {
+	query := "UPDATE products SET deleted_at = NULL WHERE id = $1 AND merchant_id = $2 AND deleted_at IS NOT NULL"
+
+	tag, err := pool.Exec(ctx, query, productId, merchantId)
+	if err != nil {
+		panic(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New("item not found")
+	}
+
+	return nil
+}
+
 func (m *MerchantRepo) GetProducts(ctx context.Context, pool *pgxpool.Pool, params *entity.ProductParams) []entity.Product {
-	query := "SELECT id, name, category, price, image_url, created_at FROM products WHERE merchant_id = @merchant_id "
+	query := "SELECT id, name, category, price, image_url, created_at FROM products WHERE merchant_id = @merchant_id AND deleted_at IS NULL "
 	args := pgx.NamedArgs{
 		"merchant_id": params.MerchantId,
 		"limit":       params.Limit,
@@ -190,7 +373,7 @@ func (m *MerchantRepo) GetProducts(ctx context.Context, pool *pgxpool.Pool, para
 
 func (m *MerchantRepo) GetTotalProduct(ctx context.Context, pool *pgxpool.Pool, params *entity.ProductParams) int {
 	var total int
-	query := "SELECT COUNT(id) products WHERE merchant_id = @merchant_id "
+	query := "SELECT COUNT(id) FROM products WHERE merchant_id = @merchant_id AND deleted_at IS NULL "
 	args := pgx.NamedArgs{
 		"merchant_id": params.MerchantId,
 	}
@@ -0,0 +1,309 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+)
+
+type OrderRepo struct{}
+
+// ExistsTx report whether the order is saved
+func (o *OrderRepo) ExistsTx(ctx context.Context, tx pgx.Tx, orderId string) (bool, error) {
+	var exists bool
+	err := tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM orders WHERE id = $1)", orderId).Scan(&exists)
+
+	return exists, err
+}
+
+// GetStatusForUpdateTx lock the order row until transaction end
+func (o *OrderRepo) GetStatusForUpdateTx(ctx context.Context, tx pgx.Tx, orderId string) (entity.OrderStatus, error) {
+	var status entity.OrderStatus
+	query := "SELECT status FROM orders WHERE id = $1 FOR UPDATE"
+
+	if err := tx.QueryRow(ctx, query, orderId).Scan(&status); err != nil {
+		return "", errors.New("order not found")
+	}
+
+	return status, nil
+}
+
+// GetForUpdateTx return owner, status and creation time of the order, the order row is locked until transaction end
+func (o *OrderRepo) GetForUpdateTx(ctx context.Context, tx pgx.Tx, orderId string) (string, entity.OrderStatus, time.Time, error) {
+	var username string
+	var status entity.OrderStatus
+	var createdAt time.Time
+	query := "SELECT username, status, created_at FROM orders WHERE id = $1 FOR UPDATE"
+
+	if err := tx.QueryRow(ctx, query, orderId).Scan(&username, &status, &createdAt); err != nil {
+		return "", "", time.Time{}, errors.New("order not found")
+	}
+
+	return username, status, createdAt, nil
+}
+
+// GetStatusByOwner return status of the order only when it belongs to username
+func (o *OrderRepo) GetStatusByOwner(ctx context.Context, pool *pgxpool.Pool, orderId string, username string) (entity.OrderStatus, error) {
+	var status entity.OrderStatus
+
+	if err := pool.QueryRow(ctx, "SELECT status FROM orders WHERE id = $1 AND username = $2", orderId, username).Scan(&status); err != nil {
+		return "", errors.New("order not found")
+	}
+
+	return status, nil
+}
+
+// GetCourierTx return courier assigned to the order, empty when no courier is assigned yet
+func (o *OrderRepo) GetCourierTx(ctx context.Context, tx pgx.Tx, orderId string) (string, error) {
+	var courier *string
+
+	if err := tx.QueryRow(ctx, "SELECT courier_username FROM orders WHERE id = $1", orderId).Scan(&courier); err != nil {
+		return "", errors.New("order not found")
+	}
+
+	if courier == nil {
+		return "", nil
+	}
+
+	return *courier, nil
+}
+
+// GetStartingPointTx return coordinate of the merchant where courier start the trip
+func (o *OrderRepo) GetStartingPointTx(ctx context.Context, tx pgx.Tx, orderId string) (*entity.Coordinate, error) {
+	location := &entity.Coordinate{}
+	query := "SELECT m.lat, m.long FROM orders o JOIN merchants m ON m.id = o.starting_merchant_id WHERE o.id = $1"
+
+	if err := tx.QueryRow(ctx, query, orderId).Scan(&location.Lat, &location.Long); err != nil {
+		return nil, errors.New("starting point not found")
+	}
+
+	return location, nil
+}
+
+func (o *OrderRepo) AssignCourierTx(ctx context.Context, tx pgx.Tx, orderId string, username string) error {
+	query := "UPDATE orders SET courier_username = $1, courier_assigned_at = NOW(), updated_at = NOW() WHERE id = $2"
+
+	_, err := tx.Exec(ctx, query, username, orderId)
+	return err
+}
+
+// GetUnassigned return accepted orders still waiting for a courier, oldest first
+func (o *OrderRepo) GetUnassigned(ctx context.Context, pool *pgxpool.Pool, limit int) ([]string, error) {
+	query := `SELECT id FROM orders
+		WHERE courier_username IS NULL AND starting_merchant_id IS NOT NULL AND status IN ($1, $2)
+		ORDER BY created_at LIMIT $3`
+
+	rows, err := pool.Query(ctx, query, entity.OrderAccepted, entity.OrderPreparing, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (o *OrderRepo) GetCharges(ctx context.Context, pool *pgxpool.Pool, orderId string) *entity.OrderCharges {
+	charges := &entity.OrderCharges{}
+	query := `SELECT subtotal, discount, delivery_fee, small_order_fee, service_fee, tax, total_price, distance_km, pricing_category,
+			COALESCE(promo_code, '')
+		FROM orders WHERE id = $1`
+
+	err := pool.QueryRow(ctx, query, orderId).Scan(&charges.Subtotal, &charges.Discount, &charges.DeliveryFee, &charges.SmallOrderFee,
+		&charges.ServiceFee, &charges.Tax, &charges.TotalPrice, &charges.DistanceKm, &charges.PricingCategory,
+		&charges.PromoCode)
+	if err != nil {
+		panic(err)
+	}
+
+	return charges
+}
+
+func (o *OrderRepo) UpdateStatusTx(ctx context.Context, tx pgx.Tx, orderId string, status entity.OrderStatus) error {
+	query := "UPDATE orders SET status = $1, updated_at = NOW() WHERE id = $2"
+
+	_, err := tx.Exec(ctx, query, status, orderId)
+	return err
+}
+
+func (o *OrderRepo) InsertStatusHistoryTx(ctx context.Context, tx pgx.Tx, orderId string, history *entity.OrderStatusHistory) error {
+	query := "INSERT INTO order_status_history(order_id, from_status, to_status, actor, reason) VALUES($1, $2, $3, $4, $5)"
+
+	_, err := tx.Exec(ctx, query, orderId, history.FromStatus, history.ToStatus, history.Actor, history.Reason)
+	return err
+}
+
+func (o *OrderRepo) GetStatusHistory(ctx context.Context, pool *pgxpool.Pool, orderId string) []entity.OrderStatusHistory {
+	query := "SELECT from_status, to_status, actor, reason, created_at FROM order_status_history WHERE order_id = $1 ORDER BY created_at, id"
+
+	rows, err := pool.Query(ctx, query, orderId)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+
+	history := []entity.OrderStatusHistory{}
+	for rows.Next() {
+		item := entity.OrderStatusHistory{}
+		if err := rows.Scan(&item.FromStatus, &item.ToStatus, &item.Actor, &item.Reason, &item.CreatedAt); err != nil {
+			panic(err)
+		}
+		history = append(history, item)
+	}
+
+	return history
+}
+
+func (o *OrderRepo) InsertTicketsTx(ctx context.Context, tx pgx.Tx, orderId string, merchantIds []string) error {
+	query := "INSERT INTO order_tickets(order_id, merchant_id) SELECT $1, UNNEST($2::CHAR(26)[]) ON CONFLICT DO NOTHING"
+
+	_, err := tx.Exec(ctx, query, orderId, merchantIds)
+	return err
+}
+
+// GetTicketForUpdateTx lock the ticket row until transaction end
+func (o *OrderRepo) GetTicketForUpdateTx(ctx context.Context, tx pgx.Tx, orderId string, merchantId string) (entity.TicketStatus, error) {
+	var status entity.TicketStatus
+	query := "SELECT status FROM order_tickets WHERE order_id = $1 AND merchant_id = $2 FOR UPDATE"
+
+	if err := tx.QueryRow(ctx, query, orderId, merchantId).Scan(&status); err != nil {
+		return "", errors.New("ticket not found")
+	}
+
+	return status, nil
+}
+
+func (o *OrderRepo) UpdateTicketTx(ctx context.Context, tx pgx.Tx, orderId string, merchantId string, status entity.TicketStatus, reason string) error {
+	query := "UPDATE order_tickets SET status = $1, reason = $2, updated_at = NOW() WHERE order_id = $3 AND merchant_id = $4"
+
+	_, err := tx.Exec(ctx, query, status, reason, orderId, merchantId)
+	return err
+}
+
+// CancelTicketsTx cancel every ticket of the order which is not rejected or cancelled yet
+func (o *OrderRepo) CancelTicketsTx(ctx context.Context, tx pgx.Tx, orderId string, reason string) error {
+	query := `UPDATE order_tickets SET status = $1, reason = $2, updated_at = NOW()
+		WHERE order_id = $3 AND status NOT IN ($4, $1)`
+
+	_, err := tx.Exec(ctx, query, entity.TicketCancelled, reason, orderId, entity.TicketRejected)
+	return err
+}
+
+func (o *OrderRepo) GetTicketStatusesTx(ctx context.Context, tx pgx.Tx, orderId string) ([]entity.TicketStatus, error) {
+	rows, err := tx.Query(ctx, "SELECT status FROM order_tickets WHERE order_id = $1", orderId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statuses := []entity.TicketStatus{}
+	for rows.Next() {
+		var status entity.TicketStatus
+		if err := rows.Scan(&status); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, rows.Err()
+}
+
+func (o *OrderRepo) GetMerchantTickets(ctx context.Context, pool *pgxpool.Pool, params *entity.MerchantTicketParams, from *time.Time, to *time.Time) []entity.MerchantTicket {
+	query := `SELECT t.order_id, t.merchant_id, o.username, t.status, o.status, t.reason, t.created_at, t.updated_at,
+		(SELECT json_agg(
+			json_build_object(
+				'itemId', oi.item_id,
+				'name', oi.product_name,
+				'productCategory', oi.product_category,
+				'price', oi.unit_price,
+				'quantity', oi.quantity
+			) ORDER BY oi.id)
+		FROM order_items oi WHERE oi.order_id = t.order_id AND oi.merchant_id = t.merchant_id) AS items
+	FROM order_tickets t JOIN orders o ON o.id = t.order_id `
+
+	where, args := o.merchantTicketFilter(params, from, to)
+	query += where + " ORDER BY t.created_at DESC LIMIT @limit OFFSET @offset"
+	args["limit"] = params.Limit
+	args["offset"] = params.Offset
+
+	rows, err := pool.Query(ctx, query, args)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+
+	tickets := []entity.MerchantTicket{}
+	for rows.Next() {
+		var itemJSON []byte
+		ticket := entity.MerchantTicket{Items: []entity.TicketItem{}}
+
+		err := rows.Scan(&ticket.OrderId, &ticket.MerchantId, &ticket.Username, &ticket.Status, &ticket.OrderStatus,
+			&ticket.Reason, &ticket.CreatedAt, &ticket.UpdatedAt, &itemJSON)
+		if err != nil {
+			panic(err)
+		}
+
+		if itemJSON != nil {
+			if err := json.Unmarshal(itemJSON, &ticket.Items); err != nil {
+				panic(err)
+			}
+		}
+
+		for _, item := range ticket.Items {
+			ticket.Subtotal += item.Price * item.Quantity
+		}
+
+		tickets = append(tickets, ticket)
+	}
+
+	return tickets
+}
+
+func (o *OrderRepo) TotalMerchantTickets(ctx context.Context, pool *pgxpool.Pool, params *entity.MerchantTicketParams, from *time.Time, to *time.Time) int {
+	where, args := o.merchantTicketFilter(params, from, to)
+
+	var total int
+	err := pool.QueryRow(ctx, "SELECT COUNT(*) FROM order_tickets t "+where, args).Scan(&total)
+	if err != nil {
+		return 0
+	}
+
+	return total
+}
+
+func (o *OrderRepo) merchantTicketFilter(params *entity.MerchantTicketParams, from *time.Time, to *time.Time) (string, pgx.NamedArgs) {
+	where := "WHERE t.merchant_id = @merchant_id"
+	args := pgx.NamedArgs{
+		"merchant_id": params.MerchantId,
+	}
+
+	if params.Status != "" {
+		where += " AND t.status = @status"
+		args["status"] = params.Status
+	}
+
+	if from != nil {
+		where += " AND t.created_at >= @from"
+		args["from"] = *from
+	}
+
+	if to != nil {
+		where += " AND t.created_at < @to"
+		args["to"] = *to
+	}
+
+	return where, args
+}
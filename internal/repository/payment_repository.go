@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+)
+
+type PaymentRepo struct{}
+
+const paymentColumns = "id, order_id, username, provider, COALESCE(reference, ''), method, amount, captured_amount, refunded_amount, status, created_at, updated_at"
+
+const insertPaymentQuery = `INSERT INTO payments(id, order_id, username, provider, reference, method, amount, status)
+	VALUES(@id, @order_id, @username, @provider, NULLIF(@reference, ''), @method, @amount, @status)`
+
+// Insert save payment before its order exists, e.g. pending payment before the provider is called
+func (p *PaymentRepo) Insert(ctx context.Context, pool *pgxpool.Pool, payment *entity.Payment) error {
+	_, err := pool.Exec(ctx, insertPaymentQuery, p.insertArgs(payment))
+	return err
+}
+
+func (p *PaymentRepo) InsertTx(ctx context.Context, tx pgx.Tx, payment *entity.Payment) error {
+	_, err := tx.Exec(ctx, insertPaymentQuery, p.insertArgs(payment))
+	return err
+}
+
+func (p *PaymentRepo) insertArgs(payment *entity.Payment) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"id":        payment.Id,
+		"order_id":  payment.OrderId,
+		"username":  payment.Username,
+		"provider":  payment.Provider,
+		"reference": payment.Reference,
+		"method":    payment.Method,
+		"amount":    payment.Amount,
+		"status":    payment.Status,
+	}
+}
+
+// ResolvePending set the provider answer of a pending payment, nothing change when it is no longer pending
+func (p *PaymentRepo) ResolvePending(ctx context.Context, pool *pgxpool.Pool, payment *entity.Payment) error {
+	query := `UPDATE payments SET reference = NULLIF($1, ''), status = $2, updated_at = NOW() WHERE id = $3 AND status = $4`
+
+	_, err := pool.Exec(ctx, query, payment.Reference, payment.Status, payment.Id, entity.PaymentPending)
+	return err
+}
+
+func (p *PaymentRepo) GetByOrderId(ctx context.Context, pool *pgxpool.Pool, orderId string) (*entity.Payment, error) {
+	payment, err := p.scan(pool.QueryRow(ctx, "SELECT "+paymentColumns+" FROM payments WHERE order_id = $1", orderId))
+	if err != nil {
+		return nil, errors.New("payment not found")
+	}
+
+	return payment, nil
+}
+
+// GetForUpdateTx lock the payment of the order until transaction end
+func (p *PaymentRepo) GetForUpdateTx(ctx context.Context, tx pgx.Tx, orderId string) (*entity.Payment, error) {
+	payment, err := p.scan(tx.QueryRow(ctx, "SELECT "+paymentColumns+" FROM payments WHERE order_id = $1 FOR UPDATE", orderId))
+	if err != nil {
+		return nil, errors.New("payment not found")
+	}
+
+	return payment, nil
+}
+
+func (p *PaymentRepo) GetByReferenceForUpdateTx(ctx context.Context, tx pgx.Tx, provider string, reference string) (*entity.Payment, error) {
+	query := "SELECT " + paymentColumns + " FROM payments WHERE provider = $1 AND reference = $2 FOR UPDATE"
+
+	payment, err := p.scan(tx.QueryRow(ctx, query, provider, reference))
+	if err != nil {
+		return nil, errors.New("payment not found")
+	}
+
+	return payment, nil
+}
+
+// GetUnsettled return order id of authorized payments whose order is finished
+func (p *PaymentRepo) GetUnsettled(ctx context.Context, pool *pgxpool.Pool, limit int) ([]string, error) {
+	query := `SELECT p.order_id FROM payments p JOIN orders o ON o.id = p.order_id
+		WHERE p.status = $1 AND o.status IN ($2, $3, $4)
+		ORDER BY p.created_at LIMIT $5`
+
+	return p.scanIds(pool.Query(ctx, query, entity.PaymentAuthorized, entity.OrderDelivered, entity.OrderCancelled, entity.OrderRejected, limit))
+}
+
+func (p *PaymentRepo) scanIds(rows pgx.Rows, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// GetOrphaned return order id of pending or authorized payments older than staleAfter whose order was never saved
+func (p *PaymentRepo) GetOrphaned(ctx context.Context, pool *pgxpool.Pool, staleAfter time.Duration, limit int) ([]string, error) {
+	query := `SELECT p.order_id FROM payments p
+		WHERE p.status IN ($1, $2) AND p.created_at < NOW() - make_interval(secs => $3)
+			AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.id = p.order_id)
+		ORDER BY p.created_at LIMIT $4`
+
+	return p.scanIds(pool.Query(ctx, query, entity.PaymentPending, entity.PaymentAuthorized, staleAfter.Seconds(), limit))
+}
+
+func (p *PaymentRepo) UpdateTx(ctx context.Context, tx pgx.Tx, payment *entity.Payment) error {
+	query := `UPDATE payments SET reference = NULLIF($1, ''), status = $2, captured_amount = $3, refunded_amount = $4, updated_at = NOW() WHERE id = $5`
+
+	_, err := tx.Exec(ctx, query, payment.Reference, payment.Status, payment.CapturedAmount, payment.RefundedAmount, payment.Id)
+	return err
+}
+
+func (p *PaymentRepo) scan(row pgx.Row) (*entity.Payment, error) {
+	payment := &entity.Payment{}
+	err := row.Scan(&payment.Id, &payment.OrderId, &payment.Username, &payment.Provider, &payment.Reference, &payment.Method, &payment.Amount,
+		&payment.CapturedAmount, &payment.RefundedAmount, &payment.Status, &payment.CreatedAt, &payment.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+)
+
+type PricingRepo struct{}
+
+const pricingColumns = "category, base_fee, per_km_fee, extra_merchant_fee, minimum_order, small_order_threshold, small_order_fee, free_delivery_threshold, updated_at"
+
+func (p *PricingRepo) GetAll(ctx context.Context, pool *pgxpool.Pool) []entity.PricingRule {
+	rows, err := pool.Query(ctx, "SELECT "+pricingColumns+" FROM pricing_rules ORDER BY category")
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+
+	rules := []entity.PricingRule{}
+	for rows.Next() {
+		rule, err := p.scan(rows)
+		if err != nil {
+			panic(err)
+		}
+		rules = append(rules, *rule)
+	}
+
+	return rules
+}
+
+func (p *PricingRepo) GetByCategory(ctx context.Context, pool *pgxpool.Pool, category string) (*entity.PricingRule, error) {
+	rule, err := p.scan(pool.QueryRow(ctx, "SELECT "+pricingColumns+" FROM pricing_rules WHERE category = $1", category))
+	if err != nil {
+		return nil, errors.New("pricing rule not found")
+	}
+
+	return rule, nil
+}
+
+func (p *PricingRepo) Upsert(ctx context.Context, pool *pgxpool.Pool, rule *entity.PricingRule) error {
+	query := `INSERT INTO pricing_rules(category, base_fee, per_km_fee, extra_merchant_fee, minimum_order, small_order_threshold, small_order_fee, free_delivery_threshold)
+		VALUES(@category, @base_fee, @per_km_fee, @extra_merchant_fee, @minimum_order, @small_order_threshold, @small_order_fee, @free_delivery_threshold)
+		ON CONFLICT (category) DO UPDATE SET
+			base_fee = EXCLUDED.base_fee,
+			per_km_fee = EXCLUDED.per_km_fee,
+			extra_merchant_fee = EXCLUDED.extra_merchant_fee,
+			minimum_order = EXCLUDED.minimum_order,
+			small_order_threshold = EXCLUDED.small_order_threshold,
+			small_order_fee = EXCLUDED.small_order_fee,
+			free_delivery_threshold = EXCLUDED.free_delivery_threshold,
+			updated_at = NOW()
+		RETURNING updated_at`
+	args := pgx.NamedArgs{
+		"category":                rule.Category,
+		"base_fee":                rule.BaseFee,
+		"per_km_fee":              rule.PerKmFee,
+		"extra_merchant_fee":      rule.ExtraMerchantFee,
+		"minimum_order":           rule.MinimumOrder,
+		"small_order_threshold":   rule.SmallOrderThreshold,
+		"small_order_fee":         rule.SmallOrderFee,
+		"free_delivery_threshold": rule.FreeDeliveryThreshold,
+	}
+
+	return pool.QueryRow(ctx, query, args).Scan(&rule.UpdatedAt)
+}
+
+func (p *PricingRepo) Delete(ctx context.Context, pool *pgxpool.Pool, category string) error {
+	tag, err := pool.Exec(ctx, "DELETE FROM pricing_rules WHERE category = $1", category)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New("pricing rule not found")
+	}
+
+	return nil
+}
+
+func (p *PricingRepo) scan(row pgx.Row) (*entity.PricingRule, error) {
+	rule := &entity.PricingRule{}
+	err := row.Scan(&rule.Category, &rule.BaseFee, &rule.PerKmFee, &rule.ExtraMerchantFee, &rule.MinimumOrder,
+		&rule.SmallOrderThreshold, &rule.SmallOrderFee, &rule.FreeDeliveryThreshold, &rule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
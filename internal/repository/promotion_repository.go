@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+)
+
+type PromotionRepo struct{}
+
+const promotionColumns = `code, description, discount_type, discount_value, max_discount, merchant_id, merchant_category, min_spend,
+	usage_limit, usage_limit_per_user, used_count, starts_at, ends_at, active, created_at`
+
+func (p *PromotionRepo) Insert(ctx context.Context, pool *pgxpool.Pool, promotion *entity.Promotion, createdBy string) error {
+	query := `INSERT INTO promotions(code, description, discount_type, discount_value, max_discount, merchant_id, merchant_category,
+			min_spend, usage_limit, usage_limit_per_user, starts_at, ends_at, created_by)
+		VALUES(@code, @description, @discount_type, @discount_value, @max_discount, @merchant_id, @merchant_category,
+			@min_spend, @usage_limit, @usage_limit_per_user, @starts_at, @ends_at, @created_by)
+		ON CONFLICT DO NOTHING
+		RETURNING active, created_at`
+	args := pgx.NamedArgs{
+		"code":                 promotion.Code,
+		"description":          promotion.Description,
+		"discount_type":        promotion.DiscountType,
+		"discount_value":       promotion.DiscountValue,
+		"max_discount":         promotion.MaxDiscount,
+		"merchant_id":          promotion.MerchantId,
+		"merchant_category":    promotion.MerchantCategory,
+		"min_spend":            promotion.MinSpend,
+		"usage_limit":          promotion.UsageLimit,
+		"usage_limit_per_user": promotion.UsageLimitPerUser,
+		"starts_at":            promotion.StartsAt,
+		"ends_at":              promotion.EndsAt,
+		"created_by":           createdBy,
+	}
+
+	if err := pool.QueryRow(ctx, query, args).Scan(&promotion.Active, &promotion.CreatedAt); err != nil {
+		return errors.New("promotion code already exists")
+	}
+
+	return nil
+}
+
+func (p *PromotionRepo) GetAll(ctx context.Context, pool *pgxpool.Pool, params *entity.PromotionParams) []entity.Promotion {
+	query := "SELECT " + promotionColumns + " FROM promotions WHERE TRUE"
+	args := pgx.NamedArgs{
+		"limit":  params.Limit,
+		"offset": params.Offset,
+	}
+
+	if params.Active != nil {
+		query += " AND active = @active"
+		args["active"] = *params.Active
+	}
+
+	query += " ORDER BY created_at DESC LIMIT @limit OFFSET @offset"
+
+	rows, err := pool.Query(ctx, query, args)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+
+	promotions := []entity.Promotion{}
+	for rows.Next() {
+		promotion, err := p.scan(rows)
+		if err != nil {
+			panic(err)
+		}
+		promotions = append(promotions, *promotion)
+	}
+
+	return promotions
+}
+
+func (p *PromotionRepo) GetByCode(ctx context.Context, pool *pgxpool.Pool, code string) (*entity.Promotion, error) {
+	promotion, err := p.scan(pool.QueryRow(ctx, "SELECT "+promotionColumns+" FROM promotions WHERE code = $1", code))
+	if err != nil {
+		return nil, errors.New("promotion not found")
+	}
+
+	return promotion, nil
+}
+
+// GetForUpdateTx lock the promotion row until transaction end, so usage limit can't be exceeded by concurrent orders
+func (p *PromotionRepo) GetForUpdateTx(ctx context.Context, tx pgx.Tx, code string) (*entity.Promotion, error) {
+	promotion, err := p.scan(tx.QueryRow(ctx, "SELECT "+promotionColumns+" FROM promotions WHERE code = $1 FOR UPDATE", code))
+	if err != nil {
+		return nil, errors.New("promotion not found")
+	}
+
+	return promotion, nil
+}
+
+func (p *PromotionRepo) CountRedemptions(ctx context.Context, pool *pgxpool.Pool, code string, username string) int {
+	var total int
+	query := "SELECT COUNT(*) FROM promotion_redemptions WHERE code = $1 AND username = $2"
+
+	if err := pool.QueryRow(ctx, query, code, username).Scan(&total); err != nil {
+		panic(err)
+	}
+
+	return total
+}
+
+func (p *PromotionRepo) CountRedemptionsTx(ctx context.Context, tx pgx.Tx, code string, username string) (int, error) {
+	var total int
+	query := "SELECT COUNT(*) FROM promotion_redemptions WHERE code = $1 AND username = $2"
+
+	err := tx.QueryRow(ctx, query, code, username).Scan(&total)
+	return total, err
+}
+
+// RedeemTx record the promotion use by the order and count it toward the global limit
+func (p *PromotionRepo) RedeemTx(ctx context.Context, tx pgx.Tx, code string, username string, orderId string, discount int) error {
+	query := "INSERT INTO promotion_redemptions(code, username, order_id, discount) VALUES($1, $2, $3, $4)"
+	if _, err := tx.Exec(ctx, query, code, username, orderId, discount); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(ctx, "UPDATE promotions SET used_count = used_count + 1, updated_at = NOW() WHERE code = $1", code)
+	return err
+}
+
+func (p *PromotionRepo) Deactivate(ctx context.Context, pool *pgxpool.Pool, code string) error {
+	tag, err := pool.Exec(ctx, "UPDATE promotions SET active = false, updated_at = NOW() WHERE code = $1", code)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.New("promotion not found")
+	}
+
+	return nil
+}
+
+func (p *PromotionRepo) scan(row pgx.Row) (*entity.Promotion, error) {
+	promotion := &entity.Promotion{}
+	err := row.Scan(&promotion.Code, &promotion.Description, &promotion.DiscountType, &promotion.DiscountValue,
+		&promotion.MaxDiscount, &promotion.MerchantId, &promotion.MerchantCategory, &promotion.MinSpend,
+		&promotion.UsageLimit, &promotion.UsageLimitPerUser, &promotion.UsedCount, &promotion.StartsAt,
+		&promotion.EndsAt, &promotion.Active, &promotion.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return promotion, nil
+}
@@ -17,6 +17,9 @@ import (
 
 type PurchaseRepo struct{}
 
+// merchantRatingAvg derive average rating of merchant m from its aggregate
+const merchantRatingAvg = "(CASE WHEN m.rating_count = 0 THEN 0 ELSE ROUND(m.rating_sum::NUMERIC / m.rating_count, 2) END)::FLOAT8"
+
 func (p *PurchaseRepo) GetMerchantNearby(ctx context.Context, pool *pgxpool.Pool, lat float64, long float64, params *converter.MerchanNearbyParams) []converter.MerchanNearby {
 	userGeohash := geohash.Encode(lat, long)
 	geoPrefix := userGeohash[:3]
@@ -30,6 +33,8 @@ func (p *PurchaseRepo) GetMerchantNearby(ctx context.Context, pool *pgxpool.Pool
 		m.lat,
 		m.long,
 		m.created_at AS merchantCreatedAt,
+		` + merchantRatingAvg + ` AS rating,
+		m.rating_count,
 		(SELECT 
 			json_agg(
 				json_build_object(
@@ -41,12 +46,12 @@ func (p *PurchaseRepo) GetMerchantNearby(ctx context.Context, pool *pgxpool.Pool
 					'createdAt', p.created_at
 				)
 			)
-		FROM products p WHERE m.id = p.merchant_id) AS items,
+		FROM products p WHERE m.id = p.merchant_id AND p.deleted_at IS NULL) AS items,
 		haversine(@lat, @long, lat, long) AS distance
 	FROM
 		merchants m
 	WHERE
-		m.geohash LIKE @geoparam
+		m.geohash LIKE @geoparam AND m.deleted_at IS NULL
 	`
 
 	args := pgx.NamedArgs{
@@ -72,8 +77,20 @@ func (p *PurchaseRepo) GetMerchantNearby(ctx context.Context, pool *pgxpool.Pool
 		args["m_name"] = params.Name
 	}
 
+	if params.MinRating > 0 {
+		query += " AND " + merchantRatingAvg + " >= @min_rating"
+		args["min_rating"] = params.MinRating
+	}
+
+	if params.SortBy == "rating" {
+		query += `
+	ORDER BY rating DESC, m.rating_count DESC, distance`
+	} else {
+		query += `
+	ORDER BY distance`
+	}
+
 	query += `
-	ORDER BY distance
 	LIMIT @limit OFFSET @offset;`
 
 	rows, err := pool.Query(ctx, query, args)
@@ -87,11 +104,15 @@ func (p *PurchaseRepo) GetMerchantNearby(ctx context.Context, pool *pgxpool.Pool
 	for rows.Next() {
 		var products []entity.Product = []entity.Product{}
 		var productJSON []byte
+		var rating float64
+		var ratingCount int
 		merchant := &entity.Merchant{
-			Location: &entity.Coordinate{},
+			Location:    &entity.Coordinate{},
+			Rating:      &rating,
+			RatingCount: &ratingCount,
 		}
 
-		rows.Scan(&merchant.Id, &merchant.Name, &merchant.Category, &merchant.ImageUrl, &merchant.Location.Lat, &merchant.Location.Long, &merchant.CreatedAt, &productJSON, nil)
+		rows.Scan(&merchant.Id, &merchant.Name, &merchant.Category, &merchant.ImageUrl, &merchant.Location.Lat, &merchant.Location.Long, &merchant.CreatedAt, &rating, &ratingCount, &productJSON, nil)
 
 		if productJSON != nil {
 			err := json.Unmarshal(productJSON, &products)
@@ -119,7 +140,7 @@ func (p *PurchaseRepo) TotalMerchantNearby(ctx context.Context, pool *pgxpool.Po
 	FROM
 		merchants m
 	WHERE
-		m.geohash LIKE @geoparam
+		m.geohash LIKE @geoparam AND m.deleted_at IS NULL
 	`
 
 	args := pgx.NamedArgs{
@@ -141,6 +162,11 @@ func (p *PurchaseRepo) TotalMerchantNearby(ctx context.Context, pool *pgxpool.Po
 		args["m_name"] = params.Name
 	}
 
+	if params.MinRating > 0 {
+		query += " AND " + merchantRatingAvg + " >= @min_rating"
+		args["min_rating"] = params.MinRating
+	}
+
 	var total int
 	err := pool.QueryRow(ctx, query, args).Scan(&total)
 	if err != nil {
@@ -166,6 +192,48 @@ func (p *PurchaseRepo) GetMerchantBydIds(ctx context.Context, pool *pgxpool.Pool
 
 }
 
+func (p *PurchaseRepo) InsertOrderTx(ctx context.Context, tx pgx.Tx, orderId string, estimate *entity.Estimate) error {
+	query := `INSERT INTO orders(id, username, total_price, estimated_delivery_time, starting_merchant_id, user_lat, user_long,
+			subtotal, discount, delivery_fee, small_order_fee, service_fee, tax, distance_km, pricing_category, promo_code)
+		VALUES(@id, @username, @total_price, @estimated_delivery_time, NULLIF(@starting_merchant_id, ''), @user_lat, @user_long,
+			@subtotal, @discount, @delivery_fee, @small_order_fee, @service_fee, @tax, @distance_km, @pricing_category, NULLIF(@promo_code, ''))`
+	args := pgx.NamedArgs{
+		"id":                      orderId,
+		"username":                estimate.Username,
+		"total_price":             estimate.TotalPrice,
+		"estimated_delivery_time": estimate.EstimatedDeliveryTime,
+		"starting_merchant_id":    estimate.StartingMerchantId,
+		"user_lat":                estimate.UserLocation.Lat,
+		"user_long":               estimate.UserLocation.Long,
+		"subtotal":                estimate.Subtotal,
+		"discount":                estimate.Discount,
+		"delivery_fee":            estimate.DeliveryFee,
+		"small_order_fee":         estimate.SmallOrderFee,
+		"service_fee":             estimate.ServiceFee,
+		"tax":                     estimate.Tax,
+		"distance_km":             estimate.DistanceKm,
+		"pricing_category":        estimate.PricingCategory,
+		"promo_code":              estimate.PromoCode,
+	}
+
+	_, err := tx.Exec(ctx, query, args)
+	return err
+}
+
+func (p *PurchaseRepo) InsertOrderItemsTx(ctx context.Context, tx pgx.Tx, orderId string, items []entity.EstimateItem) error {
+	columns := []string{"order_id", "merchant_id", "item_id", "quantity", "unit_price", "product_name", "product_category",
+		"product_image_url", "merchant_name", "merchant_category", "merchant_image_url"}
+
+	rows := make([][]interface{}, 0, len(items))
+	for _, item := range items {
+		rows = append(rows, []interface{}{orderId, item.MerchantId, item.ProductId, item.Quantity, item.Price, item.ProductName,
+			item.ProductCategory, item.ProductImageUrl, item.MerchantName, item.MerchantCategory, item.MerchantImageUrl})
+	}
+
+	_, err := tx.CopyFrom(ctx, pgx.Identifier{"order_items"}, columns, pgx.CopyFromRows(rows))
+	return err
+}
+
 func (p *PurchaseRepo) GetHistory(ctx context.Context, pool *pgxpool.Pool, params *entity.OrderHistoryParams) []entity.OrderHistory {
 
 	rows, err := pool.Query(ctx, p.generateQueryOrderHistory(params))
@@ -175,16 +243,21 @@ func (p *PurchaseRepo) GetHistory(ctx context.Context, pool *pgxpool.Pool, param
 	defer rows.Close()
 
 	history := []entity.OrderHistory{}
+	orderIds := []string{}
+	orders := make(map[string]*entity.OrderHistory)
 	merchants := make(map[string]map[string]*entity.OrderDetail)
+	merchantIds := make(map[string][]string)
 
 	for rows.Next() {
 		var orderID, merchantID, merchantName, merchantCategory, merchantImageURL, productID, productName, productCategory, productImageURL string
 		var merchantLat, merchantLong float64
-		var productPrice float64
+		var status entity.OrderStatus
+		var totalPrice, refundedAmount, deliveryTime, productPrice int
 		var orderItemQuantity int
-		var merchantCreatedAt, orderItemCreatedAt time.Time
+		var orderCreatedAt, merchantCreatedAt, orderItemCreatedAt time.Time
 
-		err := rows.Scan(&orderID, &merchantID, &merchantName,
+		err := rows.Scan(&orderID, &status, &totalPrice, &refundedAmount, &deliveryTime, &orderCreatedAt,
+			&merchantID, &merchantName,
 			&merchantCategory, &merchantImageURL, &merchantLat,
 			&merchantLong, &merchantCreatedAt, &productID,
 			&productName, &productCategory, &productPrice,
@@ -194,11 +267,22 @@ func (p *PurchaseRepo) GetHistory(ctx context.Context, pool *pgxpool.Pool, param
 			panic(err)
 		}
 
-		if _, exist := merchants[orderID][merchantID]; exist == false {
-			if _, order := merchants[orderID]; order == false {
-				merchants[orderID] = make(map[string]*entity.OrderDetail)
+		if _, order := orders[orderID]; order == false {
+			orderIds = append(orderIds, orderID)
+			orders[orderID] = &entity.OrderHistory{
+				OrderId:                        orderID,
+				Status:                         status,
+				TotalPrice:                     totalPrice,
+				RefundedAmount:                 refundedAmount,
+				EstimatedDeliveryTimeInMinutes: deliveryTime,
+				CreatedAt:                      &orderCreatedAt,
+				Orders:                         []entity.OrderDetail{},
 			}
+			merchants[orderID] = make(map[string]*entity.OrderDetail)
+		}
 
+		if _, exist := merchants[orderID][merchantID]; exist == false {
+			merchantIds[orderID] = append(merchantIds[orderID], merchantID)
 			merchants[orderID][merchantID] = &entity.OrderDetail{
 				Merchant: entity.Merchant{
 					Id:       merchantID,
@@ -229,22 +313,14 @@ func (p *PurchaseRepo) GetHistory(ctx context.Context, pool *pgxpool.Pool, param
 		})
 	}
 
-	orderMap := make(map[string]*entity.OrderHistory)
-
-	for orderId, order := range merchants {
-		orderMap[orderId] = &entity.OrderHistory{
-			OrderId: orderId,
-			Orders:  []entity.OrderDetail{},
-		}
-
-		for _, detail := range order {
-			order := orderMap[orderId]
-			order.Orders = append(order.Orders, *detail)
+	// keep the newest order first
+	for _, orderId := range orderIds {
+		order := orders[orderId]
+		for _, merchantId := range merchantIds[orderId] {
+			order.Orders = append(order.Orders, *merchants[orderId][merchantId])
 		}
-	}
 
-	for _, orderResponse := range orderMap {
-		history = append(history, *orderResponse)
+		history = append(history, *order)
 	}
 
 	return history
@@ -253,51 +329,66 @@ func (p *PurchaseRepo) GetHistory(ctx context.Context, pool *pgxpool.Pool, param
 func (p *PurchaseRepo) generateQueryOrderHistory(params *entity.OrderHistoryParams) string {
 	query := `
 		WITH limited_orders AS (
-		SELECT *
+		SELECT *,
+			(SELECT COALESCE(SUM(rf.amount), 0) FROM refunds rf WHERE rf.order_id = orders.id AND rf.status <> '` + string(entity.RefundFailed) + `') AS refunded_amount
 		FROM orders
-		WHERE username = '` + db.Escape(params.Username) + `'
+		WHERE username = '` + db.Escape(params.Username) + `'`
+
+	if params.OrderId != "" {
+		query += ` AND id = '` + db.Escape(params.OrderId) + `'`
+	}
+
+	query += `
 		ORDER BY created_at DESC
 		LIMIT ` + strconv.Itoa(int(params.Limit)) + ` 
 		OFFSET ` + strconv.Itoa(int(params.Offset)) + `
 		)
 	`
 
+	// item and merchant detail come from snapshot at order time, only location is read from merchants
 	query += `
 		SELECT 
 			lo.id as order_id,
-			m.id as merchant_id,
-			m.name as merchant_name,
-			m.category as merchant_category,
-			m.image_url as merchant_image_url,
+			lo.status as order_status,
+			lo.total_price as order_total_price,
+			lo.refunded_amount as order_refunded_amount,
+			lo.estimated_delivery_time as order_estimated_delivery_time,
+			lo.created_at as order_created_at,
+			oi.merchant_id as merchant_id,
+			oi.merchant_name as merchant_name,
+			oi.merchant_category as merchant_category,
+			oi.merchant_image_url as merchant_image_url,
 			m.lat as merchant_location_lat,
 			m.long as merchant_location_long,
 			m.created_at as merchant_created_at,
-			p.id as product_id,
-			p.name as product_name,
-			p.category as product_category,
-			p.price as product_price,
-			p.image_url as product_image_url,
+			oi.item_id as product_id,
+			oi.product_name as product_name,
+			oi.product_category as product_category,
+			oi.unit_price as product_price,
+			oi.product_image_url as product_image_url,
 			oi.quantity as order_item_quantity,
 			oi.created_at as order_item_created_at
 		FROM limited_orders lo
 		JOIN order_items oi ON lo.id = oi.order_id
-		JOIN products p ON oi.item_id = p.id
+		-- deleted_at is not checked, history keep showing soft deleted merchants
 		JOIN merchants m ON oi.merchant_id = m.id
 		WHERE TRUE
 	`
 
 	if params.MerchantId != "" {
-		query += " AND m.id = '" + db.Escape(params.MerchantId) + "'"
+		query += " AND oi.merchant_id = '" + db.Escape(params.MerchantId) + "'"
 	}
 
 	if params.Name != "" {
-		query += " AND (LOWER(m.name) LIKE '%" + db.Escape(strings.ToLower(params.Name))
-		query += "%' OR LOWER(p.name) LIKE '%" + db.Escape(strings.ToLower(params.Name)) + "%')"
+		query += " AND (LOWER(oi.merchant_name) LIKE '%" + db.Escape(strings.ToLower(params.Name))
+		query += "%' OR LOWER(oi.product_name) LIKE '%" + db.Escape(strings.ToLower(params.Name)) + "%')"
 	}
 
 	if params.MerchantCategory != "" {
-		query += " AND m.category = '" + db.Escape(params.MerchantCategory) + "'"
+		query += " AND oi.merchant_category = '" + db.Escape(params.MerchantCategory) + "'"
 	}
 
+	query += " ORDER BY lo.created_at DESC, oi.id"
+
 	return query
 }
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+)
+
+type RefundRepo struct{}
+
+// GetRefundableItemsTx return items of the order snapshot with quantity not refunded yet
+func (r *RefundRepo) GetRefundableItemsTx(ctx context.Context, tx pgx.Tx, orderId string) ([]entity.RefundItem, error) {
+	query := `SELECT oi.merchant_id, oi.item_id, MIN(oi.product_name), MIN(oi.unit_price),
+			SUM(oi.quantity) - COALESCE((
+				SELECT SUM(ri.quantity) FROM refund_items ri JOIN refunds rf ON rf.id = ri.refund_id
+				WHERE rf.order_id = oi.order_id AND ri.merchant_id = oi.merchant_id AND ri.item_id = oi.item_id AND rf.status <> $2
+			), 0)
+		FROM order_items oi
+		WHERE oi.order_id = $1
+		GROUP BY oi.order_id, oi.merchant_id, oi.item_id`
+
+	rows, err := tx.Query(ctx, query, orderId, entity.RefundFailed)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []entity.RefundItem{}
+	for rows.Next() {
+		item := entity.RefundItem{}
+		if err := rows.Scan(&item.MerchantId, &item.ItemId, &item.ProductName, &item.UnitPrice, &item.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *RefundRepo) InsertTx(ctx context.Context, tx pgx.Tx, refund *entity.Refund) error {
+	query := `INSERT INTO refunds(id, order_id, amount, reason, destination, status, created_by)
+		VALUES(@id, @order_id, @amount, @reason, @destination, @status, @created_by)`
+	args := pgx.NamedArgs{
+		"id":          refund.Id,
+		"order_id":    refund.OrderId,
+		"amount":      refund.Amount,
+		"reason":      refund.Reason,
+		"destination": refund.Destination,
+		"status":      refund.Status,
+		"created_by":  refund.CreatedBy,
+	}
+
+	if _, err := tx.Exec(ctx, query, args); err != nil {
+		return err
+	}
+
+	if len(refund.Items) == 0 {
+		return nil
+	}
+
+	columns := []string{"refund_id", "merchant_id", "item_id", "product_name", "unit_price", "quantity", "amount"}
+	rows := [][]interface{}{}
+	for _, item := range refund.Items {
+		rows = append(rows, []interface{}{refund.Id, item.MerchantId, item.ItemId, item.ProductName, item.UnitPrice, item.Quantity, item.Amount})
+	}
+
+	_, err := tx.CopyFrom(ctx, pgx.Identifier{"refund_items"}, columns, pgx.CopyFromRows(rows))
+	return err
+}
+
+// SettlePendingTx set the result of a pending refund, false is returned when it is already settled
+func (r *RefundRepo) SettlePendingTx(ctx context.Context, tx pgx.Tx, refundId string, status entity.RefundStatus) (bool, error) {
+	tag, err := tx.Exec(ctx, "UPDATE refunds SET status = $1 WHERE id = $2 AND status = $3", status, refundId, entity.RefundPending)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// GetPending return refunds still pending after staleAfter, without their items
+func (r *RefundRepo) GetPending(ctx context.Context, pool *pgxpool.Pool, staleAfter time.Duration, limit int) ([]entity.Refund, error) {
+	query := `SELECT id, order_id, amount FROM refunds
+		WHERE status = $1 AND created_at < NOW() - make_interval(secs => $2)
+		ORDER BY created_at LIMIT $3`
+
+	rows, err := pool.Query(ctx, query, entity.RefundPending, staleAfter.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds := []entity.Refund{}
+	for rows.Next() {
+		refund := entity.Refund{Status: entity.RefundPending}
+		if err := rows.Scan(&refund.Id, &refund.OrderId, &refund.Amount); err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+
+	return refunds, rows.Err()
+}
+
+func (r *RefundRepo) GetByOrderId(ctx context.Context, pool *pgxpool.Pool, orderId string) []entity.Refund {
+	query := `SELECT rf.id, rf.order_id, rf.amount, rf.reason, rf.destination, rf.status, rf.created_by, rf.created_at,
+			ri.merchant_id, ri.item_id, ri.product_name, ri.unit_price, ri.quantity, ri.amount
+		FROM refunds rf LEFT JOIN refund_items ri ON ri.refund_id = rf.id
+		WHERE rf.order_id = $1
+		ORDER BY rf.created_at, rf.id, ri.id`
+
+	refunds := []entity.Refund{}
+
+	rows, err := pool.Query(ctx, query, orderId)
+	if err != nil {
+		return refunds
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var refund entity.Refund
+		var createdAt time.Time
+		var merchantId, itemId, productName *string
+		var unitPrice, quantity, amount *int
+
+		err := rows.Scan(&refund.Id, &refund.OrderId, &refund.Amount, &refund.Reason, &refund.Destination, &refund.Status, &refund.CreatedBy, &createdAt,
+			&merchantId, &itemId, &productName, &unitPrice, &quantity, &amount)
+		if err != nil {
+			return refunds
+		}
+
+		if len(refunds) == 0 || refunds[len(refunds)-1].Id != refund.Id {
+			refund.CreatedAt = &createdAt
+			refund.Items = []entity.RefundItem{}
+			refunds = append(refunds, refund)
+		}
+
+		if itemId != nil {
+			last := &refunds[len(refunds)-1]
+			last.Items = append(last.Items, entity.RefundItem{
+				MerchantId:  *merchantId,
+				ItemId:      *itemId,
+				ProductName: *productName,
+				UnitPrice:   *unitPrice,
+				Quantity:    *quantity,
+				Amount:      *amount,
+			})
+		}
+	}
+
+	return refunds
+}
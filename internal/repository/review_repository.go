@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/malikfajr/beli-mang/internal/entity"
+)
+
+type ReviewRepo struct{}
+
+// MerchantServedTx check the merchant took part in the order, rejected or cancelled ticket doesn't count
+func (r *ReviewRepo) MerchantServedTx(ctx context.Context, tx pgx.Tx, orderId string, merchantId string) bool {
+	query := "SELECT EXISTS(SELECT 1 FROM order_tickets WHERE order_id = $1 AND merchant_id = $2 AND status NOT IN ($3, $4))"
+
+	var exist bool
+	err := tx.QueryRow(ctx, query, orderId, merchantId, entity.TicketRejected, entity.TicketCancelled).Scan(&exist)
+	if err != nil {
+		return false
+	}
+
+	return exist
+}
+
+// InsertTx save the review, false is returned when the merchant is already reviewed for the order
+func (r *ReviewRepo) InsertTx(ctx context.Context, tx pgx.Tx, username string, review *entity.Review) (bool, error) {
+	query := `INSERT INTO reviews(id, order_id, merchant_id, username, rating, comment)
+		VALUES($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (order_id, merchant_id) DO NOTHING`
+
+	tag, err := tx.Exec(ctx, query, review.Id, review.OrderId, review.MerchantId, username, review.Rating, review.Comment)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// AddMerchantRatingTx fold the rating into merchant aggregate, the merchant row lock serialize concurrent reviews
+func (r *ReviewRepo) AddMerchantRatingTx(ctx context.Context, tx pgx.Tx, merchantId string, rating int) error {
+	query := `UPDATE merchants SET
+			rating_sum = rating_sum + $1,
+			rating_count = rating_count + 1
+		WHERE id = $2`
+
+	_, err := tx.Exec(ctx, query, rating, merchantId)
+	return err
+}
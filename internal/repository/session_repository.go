@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+)
+
+type SessionRepo struct{}
+
+func (s *SessionRepo) Insert(ctx context.Context, pool *pgxpool.Pool, session *entity.Session) error {
+	query := `INSERT INTO sessions(id, username, role, refresh_token_hash, expires_at) VALUES(@id, @username, @role, @hash, @expires_at)`
+	args := pgx.NamedArgs{
+		"id":         session.Id,
+		"username":   session.Username,
+		"role":       session.Role,
+		"hash":       session.RefreshTokenHash,
+		"expires_at": session.ExpiresAt,
+	}
+
+	_, err := pool.Exec(ctx, query, args)
+	return err
+}
+
+func (s *SessionRepo) GetById(ctx context.Context, pool *pgxpool.Pool, sessionId string) (*entity.Session, error) {
+	session := &entity.Session{}
+	query := "SELECT id, username, role, refresh_token_hash, expires_at, revoked_at, created_at FROM sessions WHERE id = $1 LIMIT 1"
+
+	err := pool.QueryRow(ctx, query, sessionId).Scan(&session.Id, &session.Username, &session.Role, &session.RefreshTokenHash, &session.ExpiresAt, &session.RevokedAt, &session.CreatedAt)
+	if err != nil {
+		return nil, errors.New("session not found")
+	}
+
+	return session, nil
+}
+
+// Rotate replace refresh token hash only if the old hash still active,
+// return false when another request already rotated the token
+func (s *SessionRepo) Rotate(ctx context.Context, pool *pgxpool.Pool, sessionId string, oldHash string, newHash string, expiresAt time.Time) bool {
+	query := `UPDATE sessions SET refresh_token_hash = @new_hash, expires_at = @expires_at, updated_at = NOW()
+		WHERE id = @id AND refresh_token_hash = @old_hash AND revoked_at IS NULL AND expires_at > NOW()`
+	args := pgx.NamedArgs{
+		"id":         sessionId,
+		"old_hash":   oldHash,
+		"new_hash":   newHash,
+		"expires_at": expiresAt,
+	}
+
+	tag, err := pool.Exec(ctx, query, args)
+	if err != nil {
+		panic(err)
+	}
+
+	return tag.RowsAffected() == 1
+}
+
+func (s *SessionRepo) Revoke(ctx context.Context, pool *pgxpool.Pool, sessionId string) error {
+	query := "UPDATE sessions SET revoked_at = NOW(), updated_at = NOW() WHERE id = $1 AND revoked_at IS NULL"
+
+	_, err := pool.Exec(ctx, query, sessionId)
+	return err
+}
+
+// GetRevokedSince return id of sessions revoked after the given time
+func (s *SessionRepo) GetRevokedSince(ctx context.Context, pool *pgxpool.Pool, since time.Time) ([]string, error) {
+	query := "SELECT id FROM sessions WHERE revoked_at > $1"
+
+	rows, err := pool.Query(ctx, query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
@@ -13,7 +13,7 @@ type UserRepo struct{}
 
 func (r *UserRepo) EmailExistTx(ctx context.Context, tx pgx.Tx, email string) bool {
 	var exist int
-	query := "SELECT 1 FROM users WHERE email = $1 AND admin = false LIMIT 1;"
+	query := "SELECT 1 FROM users WHERE email = $1 AND admin = false AND courier = false LIMIT 1;"
 
 	err := tx.QueryRow(ctx, query, email).Scan(&exist)
 	if err != nil {
@@ -45,7 +45,7 @@ func (r *UserRepo) InsertTx(ctx context.Context, tx pgx.Tx, user *entity.User) e
 
 func (r *UserRepo) GetByUsername(ctx context.Context, pool *pgxpool.Pool, username string) (*entity.User, error) {
 	var user = &entity.User{IsAdmin: true}
-	query := "SELECT username, password, email FROM users WHERE username = $1 AND admin = false LIMIT 1;"
+	query := "SELECT username, password, email FROM users WHERE username = $1 AND admin = false AND courier = false LIMIT 1;"
 
 	err := pool.QueryRow(ctx, query, username).Scan(&user.Username, &user.Password, &user.Email)
 	if err != nil {
@@ -12,12 +12,14 @@ import (
 )
 
 type adminHanlder struct {
-	pool *pgxpool.Pool
+	pool    *pgxpool.Pool
+	session usecase.SessionCase
 }
 
-func NewAdminHanlder(pool *pgxpool.Pool) *adminHanlder {
+func NewAdminHanlder(pool *pgxpool.Pool, session usecase.SessionCase) *adminHanlder {
 	return &adminHanlder{
-		pool: pool,
+		pool:    pool,
+		session: session,
 	}
 }
 
@@ -44,9 +46,16 @@ func (a *adminHanlder) Register(c echo.Context) error {
 		panic(err)
 	}
 
-	return c.JSON(http.StatusCreated, &entity.UserResponse{
-		Token: token.CreateToken(payload.Username, true),
-	})
+	response, err := a.session.Create(c.Request().Context(), payload.Username, token.RoleAdmin)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusCreated, response)
 }
 
 func (a *adminHanlder) Login(c echo.Context) error {
@@ -62,7 +71,21 @@ func (a *adminHanlder) Login(c echo.Context) error {
 
 	adminAuth := usecase.NewAdminAuth(a.pool)
 
-	_, err := adminAuth.Login(c.Request().Context(), payload)
+	user, err := adminAuth.Login(c.Request().Context(), payload)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	role := token.RoleAdmin
+	if user.SuperAdmin {
+		role = token.RoleSuperAdmin
+	}
+
+	response, err := a.session.Create(c.Request().Context(), payload.Username, role)
 	if err != nil {
 		ex, ok := err.(*exception.CustomError)
 		if ok {
@@ -71,7 +94,42 @@ func (a *adminHanlder) Login(c echo.Context) error {
 		panic(err)
 	}
 
-	return c.JSON(http.StatusOK, &entity.UserResponse{
-		Token: token.CreateToken(payload.Username, true),
-	})
+	return c.JSON(http.StatusOK, response)
+}
+
+func (a *adminHanlder) Refresh(c echo.Context) error {
+	payload := &entity.RefreshPayload{}
+
+	if err := c.Bind(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn’t pass validation"))
+	}
+
+	if err := c.Validate(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn’t pass validation"))
+	}
+
+	response, err := a.session.Refresh(c.Request().Context(), payload.RefreshToken, token.RoleAdmin, token.RoleSuperAdmin)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (a *adminHanlder) Logout(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+
+	if err := a.session.Revoke(c.Request().Context(), user.ID); err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.NoContent(http.StatusOK)
 }
@@ -3,13 +3,13 @@ package handler
 import (
 	"log"
 	"net/http"
-	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
 	"github.com/malikfajr/beli-mang/internal/entity"
 	"github.com/malikfajr/beli-mang/internal/entity/converter"
 	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/pkg/broker"
 	"github.com/malikfajr/beli-mang/internal/pkg/token"
 	"github.com/malikfajr/beli-mang/internal/usecase"
 )
@@ -19,10 +19,10 @@ type merchantHandler struct {
 	manageMerchant usecase.ManageMerchant
 }
 
-func NewMerchantHandler(pool *pgxpool.Pool) *merchantHandler {
+func NewMerchantHandler(pool *pgxpool.Pool, events broker.Broker) *merchantHandler {
 	return &merchantHandler{
 		pool:           pool,
-		manageMerchant: usecase.NewManageMerchant(pool),
+		manageMerchant: usecase.NewManageMerchant(pool, events),
 	}
 }
 
@@ -60,7 +60,7 @@ func (m *merchantHandler) GetAll(c echo.Context) error {
 
 	c.Bind(params)
 
-	merchants, total, err := m.manageMerchant.GetAll(c.Request().Context(), user.Username, params)
+	merchants, total, err := m.manageMerchant.GetAll(c.Request().Context(), user, params)
 	if err != nil {
 		ex, ok := err.(*exception.CustomError)
 		if ok {
@@ -83,6 +83,60 @@ func (m *merchantHandler) GetAll(c echo.Context) error {
 	return c.JSON(http.StatusOK, response)
 }
 
+func (m *merchantHandler) Update(c echo.Context) error {
+	payload := &entity.UpdateMerchantPayload{}
+	merchantId := c.Param("merchantId")
+
+	if err := c.Bind(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	if err := c.Validate(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	user := c.Get("user").(*token.JwtClaim)
+
+	merchant, err := m.manageMerchant.Update(c.Request().Context(), user, merchantId, payload)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusOK, merchant)
+}
+
+func (m *merchantHandler) Delete(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+
+	if err := m.manageMerchant.Delete(c.Request().Context(), user, c.Param("merchantId")); err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (m *merchantHandler) Restore(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+
+	if err := m.manageMerchant.Restore(c.Request().Context(), user, c.Param("merchantId")); err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
 func (m *merchantHandler) AddProduct(c echo.Context) error {
 	payload := &entity.AddProductPayload{}
 	merchantId := c.Param("merchantId")
@@ -95,7 +149,9 @@ func (m *merchantHandler) AddProduct(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
 	}
 
-	data, err := m.manageMerchant.AddProduct(c.Request().Context(), merchantId, payload)
+	user := c.Get("user").(*token.JwtClaim)
+
+	data, err := m.manageMerchant.AddProduct(c.Request().Context(), user, merchantId, payload)
 	if err != nil {
 		ex, ok := err.(*exception.CustomError)
 		if ok {
@@ -115,7 +171,7 @@ func (m *merchantHandler) GetProducts(c echo.Context) error {
 
 	c.Bind(params)
 
-	data, total, err := m.manageMerchant.GetProducts(c.Request().Context(), user.Username, params)
+	data, total, err := m.manageMerchant.GetProducts(c.Request().Context(), user, params)
 	if err != nil {
 		ex, ok := err.(*exception.CustomError)
 		if ok {
@@ -134,14 +190,161 @@ func (m *merchantHandler) GetProducts(c echo.Context) error {
 	})
 }
 
-func (m *merchantHandler) ResetCache(interval time.Duration) {
-	go func() {
-		ticker := time.NewTicker(interval)
-		defer ticker.Stop()
+func (m *merchantHandler) UpdateProduct(c echo.Context) error {
+	payload := &entity.UpdateProductPayload{}
+	merchantId := c.Param("merchantId")
+	itemId := c.Param("itemId")
+
+	if err := c.Bind(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	if err := c.Validate(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	user := c.Get("user").(*token.JwtClaim)
+
+	product, err := m.manageMerchant.UpdateProduct(c.Request().Context(), user, merchantId, itemId, payload)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusOK, product)
+}
+
+func (m *merchantHandler) DeleteProduct(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
 
-		for {
-			<-ticker.C
-			m.manageMerchant.ResetData()
+	err := m.manageMerchant.DeleteProduct(c.Request().Context(), user, c.Param("merchantId"), c.Param("itemId"))
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (m *merchantHandler) RestoreProduct(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+
+	err := m.manageMerchant.RestoreProduct(c.Request().Context(), user, c.Param("merchantId"), c.Param("itemId"))
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
 		}
-	}()
+		panic(err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (m *merchantHandler) GetOrders(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+	params := &entity.MerchantTicketParams{}
+
+	c.Bind(params)
+
+	data, total, err := m.manageMerchant.GetOrders(c.Request().Context(), user, params)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusOK, &converter.MerchantTicketResponse{
+		Data: data,
+		Meta: &converter.Meta{
+			Limit:  params.Limit,
+			Offset: params.Offset,
+			Total:  total,
+		},
+	})
+}
+
+func (m *merchantHandler) AcceptOrder(c echo.Context) error {
+	return m.updateOrder(c, entity.TicketAccepted, "")
+}
+
+func (m *merchantHandler) RejectOrder(c echo.Context) error {
+	payload := &entity.RejectTicketPayload{}
+
+	if err := c.Bind(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	if err := c.Validate(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	return m.updateOrder(c, entity.TicketRejected, payload.Reason)
+}
+
+func (m *merchantHandler) ReadyOrder(c echo.Context) error {
+	return m.updateOrder(c, entity.TicketReady, "")
+}
+
+func (m *merchantHandler) updateOrder(c echo.Context, status entity.TicketStatus, reason string) error {
+	user := c.Get("user").(*token.JwtClaim)
+
+	err := m.manageMerchant.UpdateOrder(c.Request().Context(), user, c.Param("merchantId"), c.Param("orderId"), status, reason)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (m *merchantHandler) AddMember(c echo.Context) error {
+	payload := &entity.AddMemberPayload{}
+	merchantId := c.Param("merchantId")
+
+	if err := c.Bind(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	if err := c.Validate(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	user := c.Get("user").(*token.JwtClaim)
+
+	if err := m.manageMerchant.AddMember(c.Request().Context(), user, merchantId, payload); err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.NoContent(http.StatusCreated)
+}
+
+func (m *merchantHandler) RemoveMember(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+
+	err := m.manageMerchant.RemoveMember(c.Request().Context(), user, c.Param("merchantId"), c.Param("username"))
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.NoContent(http.StatusOK)
 }
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/usecase"
+)
+
+type pricingHandler struct {
+	pricing usecase.PricingCase
+}
+
+func NewPricingHandler(pricing usecase.PricingCase) *pricingHandler {
+	return &pricingHandler{
+		pricing: pricing,
+	}
+}
+
+func (p *pricingHandler) GetAll(c echo.Context) error {
+	return c.JSON(http.StatusOK, p.pricing.GetRules(c.Request().Context()))
+}
+
+func (p *pricingHandler) Save(c echo.Context) error {
+	rule := &entity.PricingRule{}
+
+	if err := c.Bind(rule); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	if err := c.Validate(rule); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	if err := p.pricing.SaveRule(c.Request().Context(), rule); err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusOK, rule)
+}
+
+func (p *pricingHandler) Delete(c echo.Context) error {
+	if err := p.pricing.DeleteRule(c.Request().Context(), c.Param("category")); err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
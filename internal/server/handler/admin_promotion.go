@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/pkg/token"
+	"github.com/malikfajr/beli-mang/internal/usecase"
+)
+
+type promotionHandler struct {
+	promotions usecase.PromotionCase
+}
+
+func NewPromotionHandler(promotions usecase.PromotionCase) *promotionHandler {
+	return &promotionHandler{
+		promotions: promotions,
+	}
+}
+
+func (p *promotionHandler) Create(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+	promotion := &entity.Promotion{}
+
+	if err := c.Bind(promotion); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	if err := c.Validate(promotion); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	if err := p.promotions.Create(c.Request().Context(), user.Username, promotion); err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusCreated, promotion)
+}
+
+func (p *promotionHandler) GetAll(c echo.Context) error {
+	params := &entity.PromotionParams{}
+
+	c.Bind(params)
+
+	return c.JSON(http.StatusOK, p.promotions.GetAll(c.Request().Context(), params))
+}
+
+func (p *promotionHandler) Deactivate(c echo.Context) error {
+	if err := p.promotions.Deactivate(c.Request().Context(), c.Param("code")); err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/pkg/token"
+	"github.com/malikfajr/beli-mang/internal/usecase"
+)
+
+type refundHandler struct {
+	refunds usecase.RefundCase
+}
+
+func NewRefundHandler(refunds usecase.RefundCase) *refundHandler {
+	return &refundHandler{
+		refunds: refunds,
+	}
+}
+
+func (r *refundHandler) Create(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+	payload := &entity.RefundPayload{}
+
+	if err := c.Bind(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	if err := c.Validate(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	refund, err := r.refunds.Create(c.Request().Context(), user.Username, c.Param("orderId"), payload)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusCreated, refund)
+}
+
+func (r *refundHandler) GetAll(c echo.Context) error {
+	refunds, err := r.refunds.GetAll(c.Request().Context(), c.Param("orderId"))
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusOK, refunds)
+}
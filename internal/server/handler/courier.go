@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/pkg/token"
+	"github.com/malikfajr/beli-mang/internal/usecase"
+)
+
+type courierHandler struct {
+	ccase usecase.CourierCase
+}
+
+func NewCourierHandler(ccase usecase.CourierCase) *courierHandler {
+	return &courierHandler{
+		ccase: ccase,
+	}
+}
+
+func (h *courierHandler) SetStatus(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+	payload := &entity.CourierStatusPayload{}
+
+	if err := c.Bind(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	if err := c.Validate(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	courier, err := h.ccase.SetStatus(c.Request().Context(), user.Username, payload.Status)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusOK, courier)
+}
+
+func (h *courierHandler) UpdateLocation(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+	payload := &entity.CourierLocationPayload{}
+
+	if err := c.Bind(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	if err := c.Validate(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	if err := h.ccase.UpdateLocation(c.Request().Context(), user.Username, payload); err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *courierHandler) GetOrders(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+
+	return c.JSON(http.StatusOK, h.ccase.GetOrders(c.Request().Context(), user.Username))
+}
+
+func (h *courierHandler) PickUp(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+
+	if err := h.ccase.PickUp(c.Request().Context(), user.Username, c.Param("orderId")); err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *courierHandler) Deliver(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+
+	if err := h.ccase.Deliver(c.Request().Context(), user.Username, c.Param("orderId")); err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
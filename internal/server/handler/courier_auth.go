@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/pkg/token"
+	"github.com/malikfajr/beli-mang/internal/usecase"
+)
+
+type courierAuthHandler struct {
+	pool    *pgxpool.Pool
+	session usecase.SessionCase
+}
+
+func NewCourierAuthHandler(pool *pgxpool.Pool, session usecase.SessionCase) *courierAuthHandler {
+	return &courierAuthHandler{
+		pool:    pool,
+		session: session,
+	}
+}
+
+func (a *courierAuthHandler) Register(c echo.Context) error {
+	payload := &entity.User{}
+
+	if err := c.Bind(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn’t pass validation"))
+	}
+
+	if err := c.Validate(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn’t pass validation"))
+
+	}
+
+	courierAuth := usecase.NewCourierAuth(a.pool)
+
+	err := courierAuth.Insert(c.Request().Context(), payload)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	response, err := a.session.Create(c.Request().Context(), payload.Username, token.RoleCourier)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusCreated, response)
+}
+
+func (a *courierAuthHandler) Login(c echo.Context) error {
+	payload := &entity.UserLogin{}
+
+	if err := c.Bind(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn’t pass validation"))
+	}
+
+	if err := c.Validate(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn’t pass validation"))
+	}
+
+	courierAuth := usecase.NewCourierAuth(a.pool)
+
+	_, err := courierAuth.Login(c.Request().Context(), payload)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	response, err := a.session.Create(c.Request().Context(), payload.Username, token.RoleCourier)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (a *courierAuthHandler) Refresh(c echo.Context) error {
+	payload := &entity.RefreshPayload{}
+
+	if err := c.Bind(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn’t pass validation"))
+	}
+
+	if err := c.Validate(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn’t pass validation"))
+	}
+
+	response, err := a.session.Refresh(c.Request().Context(), payload.RefreshToken, token.RoleCourier)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (a *courierAuthHandler) Logout(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+
+	if err := a.session.Revoke(c.Request().Context(), user.ID); err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
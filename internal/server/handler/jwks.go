@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/malikfajr/beli-mang/internal/pkg/token"
+)
+
+type JwksHandler struct {
+}
+
+func (j *JwksHandler) Get(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+
+	return c.JSON(http.StatusOK, token.JWKS())
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/pkg/broker"
+	"github.com/malikfajr/beli-mang/internal/pkg/token"
+	"github.com/malikfajr/beli-mang/internal/usecase"
+	"golang.org/x/net/websocket"
+)
+
+// keep idle stream open through proxies
+const heartbeatInterval = 15 * time.Second
+
+type orderHandler struct {
+	ocase usecase.OrderCase
+}
+
+func NewOrderHandler(ocase usecase.OrderCase) *orderHandler {
+	return &orderHandler{
+		ocase: ocase,
+	}
+}
+
+func (o *orderHandler) Cancel(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+	payload := &entity.CancelOrderPayload{}
+
+	if err := c.Bind(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	if err := c.Validate(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	err := o.ocase.Cancel(c.Request().Context(), user.Username, c.Param("orderId"), payload.Reason)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// Events stream status changes and courier position of the order as Server-Sent Events
+func (o *orderHandler) Events(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+
+	status, subscription, err := o.ocase.Track(c.Request().Context(), user.Username, c.Param("orderId"))
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+	defer subscription.Close()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	if err := writeEvent(res, currentStatus(c.Param("orderId"), status)); err != nil || status.IsFinal() {
+		return nil
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return nil
+			}
+			if err := writeEvent(res, event); err != nil || isFinalEvent(event) {
+				return nil
+			}
+		}
+	}
+}
+
+// Socket push the same events as Events over WebSocket, messages from client are ignored
+func (o *orderHandler) Socket(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+
+	status, subscription, err := o.ocase.Track(c.Request().Context(), user.Username, c.Param("orderId"))
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+	defer subscription.Close()
+
+	// origin is not checked, the connection is authorized by token instead of cookie
+	server := websocket.Server{
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				var message string
+				for websocket.Message.Receive(ws, &message) == nil {
+				}
+			}()
+
+			if err := websocket.JSON.Send(ws, currentStatus(c.Param("orderId"), status)); err != nil || status.IsFinal() {
+				return
+			}
+
+			for {
+				select {
+				case <-closed:
+					return
+				case event, ok := <-subscription.Events:
+					if !ok {
+						return
+					}
+					if err := websocket.JSON.Send(ws, event); err != nil || isFinalEvent(event) {
+						return
+					}
+				}
+			}
+		},
+	}
+
+	server.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+func currentStatus(orderId string, status entity.OrderStatus) broker.Event {
+	return broker.Event{
+		Type:    broker.EventStatus,
+		OrderId: orderId,
+		Data: &entity.OrderStatusHistory{
+			ToStatus: status,
+		},
+		CreatedAt: time.Now(),
+	}
+}
+
+func writeEvent(res *echo.Response, event broker.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+		return err
+	}
+
+	res.Flush()
+	return nil
+}
+
+// isFinalEvent tell stream to end, nothing is published after order reach final status
+func isFinalEvent(event broker.Event) bool {
+	change, ok := event.Data.(entity.OrderStatusHistory)
+	return ok && event.Type == broker.EventStatus && change.ToStatus.IsFinal()
+}
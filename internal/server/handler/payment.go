@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/usecase"
+)
+
+type paymentHandler struct {
+	payments usecase.PaymentCase
+}
+
+func NewPaymentHandler(payments usecase.PaymentCase) *paymentHandler {
+	return &paymentHandler{
+		payments: payments,
+	}
+}
+
+// Webhook receive payment update from provider, body is read raw because the signature is computed over it
+func (p *paymentHandler) Webhook(c echo.Context) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("cannot read request body"))
+	}
+
+	if err := p.payments.HandleWebhook(c.Request().Context(), c.Request().Header, body); err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
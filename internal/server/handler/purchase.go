@@ -6,35 +6,24 @@ import (
 	"log"
 	"math"
 	"net/http"
-	"sync"
+	"strings"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
 	"github.com/malikfajr/beli-mang/internal/entity"
 	"github.com/malikfajr/beli-mang/internal/entity/converter"
 	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/pkg/routeplan"
+	"github.com/malikfajr/beli-mang/internal/pkg/routing"
 	"github.com/malikfajr/beli-mang/internal/pkg/token"
 	"github.com/malikfajr/beli-mang/internal/usecase"
 	"github.com/oklog/ulid/v2"
 )
 
-const (
-	EarthRadiusKm         = 6371        // Radius bumi dalam kilometer
-	DeliverySpeedKmPerMin = 40.0 / 60.0 // Kecepatan pengiriman dalam kilometer per menit (40 km/jam)
-)
-
-type CacheEstimate struct {
-	MerchantId string
-	ProductId  string
-	Qty        int
-	Price      int
-}
-
 type purchaseHandler struct {
-	pool     *pgxpool.Pool
-	pcase    usecase.PurchaseCase
-	estimate map[string][]CacheEstimate
-	sync.Mutex
+	pool   *pgxpool.Pool
+	pcase  usecase.PurchaseCase
+	router routing.Router
 }
 
 type PurchaseHandler interface {
@@ -42,13 +31,14 @@ type PurchaseHandler interface {
 	CreateEstimate(c echo.Context) error
 	PostOrder(c echo.Context) error
 	GetHistory(c echo.Context) error
+	GetOrder(c echo.Context) error
 }
 
-func NewPurchasehandler(pool *pgxpool.Pool) PurchaseHandler {
+func NewPurchasehandler(pool *pgxpool.Pool, pcase usecase.PurchaseCase, router routing.Router) PurchaseHandler {
 	return &purchaseHandler{
-		pool:     pool,
-		pcase:    usecase.NewPurchaseCase(pool),
-		estimate: make(map[string][]CacheEstimate, 0),
+		pool:   pool,
+		pcase:  pcase,
+		router: router,
 	}
 }
 
@@ -67,6 +57,22 @@ func (p *purchaseHandler) GetHistory(c echo.Context) error {
 
 }
 
+// GetOrder implements PurchaseHandler.
+func (p *purchaseHandler) GetOrder(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+
+	order, err := p.pcase.GetOrder(c.Request().Context(), user.Username, c.Param("orderId"))
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusOK, order)
+}
+
 func (p *purchaseHandler) GetMerchantNearby(c echo.Context) error {
 	params := &converter.MerchanNearbyParams{}
 
@@ -113,15 +119,15 @@ func (p *purchaseHandler) CreateEstimate(c echo.Context) error {
 	merchants := make(map[string]entity.Coordinate)
 	for _, order := range payload.Orders {
 		var location entity.Coordinate
-		err := p.pool.QueryRow(context.Background(), "SELECT lat, long FROM merchants WHERE id = $1", order.MerchantId).Scan(&location.Lat, &location.Long)
+		err := p.pool.QueryRow(context.Background(), "SELECT lat, long FROM merchants WHERE id = $1 AND deleted_at IS NULL", order.MerchantId).Scan(&location.Lat, &location.Long)
 		if err != nil {
 			return c.JSON(http.StatusNotFound, exception.NotFound("Merchant id not found"))
 		}
 		merchants[order.MerchantId] = location
 	}
 
-	// Calculate total price
-	totalPrice, err := p.calculateTotalPrice(payload.Orders)
+	// Snapshot the items
+	items, err := p.snapshotItems(payload.Orders)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
@@ -135,18 +141,64 @@ func (p *purchaseHandler) CreateEstimate(c echo.Context) error {
 		}
 	}
 
-	// Calculate total travel time using TSP
-	totalTravelTime := calculateTotalTravelTimeTSP(payload.UserLocation, merchants, startingPointID)
+	// Plan the fastest route through every merchant
+	route, legs, err := p.planRoute(c.Request().Context(), payload.UserLocation, merchants, startingPointID)
+	if err != nil {
+		log.Println("cannot calculate travel time, because: ", err.Error())
+		return c.JSON(http.StatusInternalServerError, exception.ServerError("failed to calculate delivery time"))
+	}
+
+	var distance float64
+	for _, leg := range legs {
+		distance += leg.DistanceKm
+	}
 
-	// Save calculation to database
-	calculationID := ulid.Make().String()
+	user := c.Get("user").(*token.JwtClaim)
+	estimate := &entity.Estimate{
+		Id:                    ulid.Make().String(),
+		Username:              user.Username,
+		PromoCode:             strings.ToUpper(payload.PromoCode),
+		EstimatedDeliveryTime: int(legs[len(legs)-1].EtaInMinutes),
+		DistanceKm:            round(distance),
+		StartingMerchantId:    startingPointID,
+		Route:                 route,
+		Legs:                  legs,
+		UserLocation:          payload.UserLocation,
+		Items:                 items,
+	}
+
+	// Calculate fees and save calculation to database
+	if err := p.pcase.PriceEstimate(c.Request().Context(), estimate); err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
 
-	go p.SaveEstimate(calculationID, payload)
+	if err := p.pcase.SaveEstimate(c.Request().Context(), estimate); err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
 
 	return c.JSON(http.StatusOK, entity.EstimateResponse{
-		TotalPrice:                     int(totalPrice),
-		EstimatedDeliveryTimeInMinutes: int(totalTravelTime),
-		CalculatedEstimateId:           calculationID,
+		CalculatedEstimateId:           estimate.Id,
+		PromoCode:                      estimate.PromoCode,
+		Subtotal:                       estimate.Subtotal,
+		Discount:                       estimate.Discount,
+		DeliveryFee:                    estimate.DeliveryFee,
+		SmallOrderFee:                  estimate.SmallOrderFee,
+		ServiceFee:                     estimate.ServiceFee,
+		Tax:                            estimate.Tax,
+		TotalPrice:                     estimate.TotalPrice,
+		EstimatedDeliveryTimeInMinutes: estimate.EstimatedDeliveryTime,
+		DistanceKm:                     estimate.DistanceKm,
+		Route:                          estimate.Route,
+		Merchants:                      estimate.Merchants(),
+		Legs:                           estimate.Legs,
 	})
 }
 
@@ -167,12 +219,12 @@ func (p *purchaseHandler) validatePayloadOrder(payload *entity.OrderPayload) (er
 
 		var merchantId string
 		var lat, long float64
-		err := p.pool.QueryRow(context.Background(), `SELECT id, lat, long FROM merchants WHERE id = $1`, order.MerchantId).Scan(&merchantId, &lat, &long)
+		err := p.pool.QueryRow(context.Background(), `SELECT id, lat, long FROM merchants WHERE id = $1 AND deleted_at IS NULL`, order.MerchantId).Scan(&merchantId, &lat, &long)
 		if err != nil {
 			return errors.New("merchant id not found"), http.StatusNotFound
 		}
 
-		distance := haversine(userLat, userLong, lat, long)
+		distance := routing.Haversine(routing.Point{Lat: userLat, Long: userLong}, routing.Point{Lat: lat, Long: long})
 		if order.StartingPoint && distance > 3 {
 			return errors.New("Merchant " + merchantId + " too far"), http.StatusBadRequest
 		}
@@ -189,7 +241,7 @@ func (p *purchaseHandler) validatePayloadOrder(payload *entity.OrderPayload) (er
 
 			var itemId string
 
-			err = p.pool.QueryRow(context.Background(), `SELECT id FROM products WHERE id = $1 AND merchant_id = $2`, item.ItemId, merchantId).Scan(&itemId)
+			err = p.pool.QueryRow(context.Background(), `SELECT id FROM products WHERE id = $1 AND merchant_id = $2 AND deleted_at IS NULL`, item.ItemId, merchantId).Scan(&itemId)
 			if err != nil {
 				return errors.New("item id " + item.ItemId + " not found"), http.StatusNotFound
 			}
@@ -201,140 +253,99 @@ func (p *purchaseHandler) validatePayloadOrder(payload *entity.OrderPayload) (er
 	return nil, 0
 }
 
-func (p *purchaseHandler) calculateTotalPrice(orders []entity.Order) (float64, error) {
-	var totalPrice float64
+func (p *purchaseHandler) snapshotItems(orders []entity.Order) ([]entity.EstimateItem, error) {
+	query := `SELECT m.name, m.category, m.image_url, p.name, p.category, p.price, p.image_url
+		FROM products p JOIN merchants m ON m.id = p.merchant_id
+		WHERE p.id = $1 AND p.merchant_id = $2 AND p.deleted_at IS NULL`
+
+	items := []entity.EstimateItem{}
 	for _, order := range orders {
 		for _, item := range order.Items {
-			var price float64
-			err := p.pool.QueryRow(context.Background(), "SELECT price FROM products WHERE id = $1", item.ItemId).Scan(&price)
-			if err != nil {
-				return 0, errors.New("item with ID " + item.ItemId + " not found")
+			estimateItem := entity.EstimateItem{
+				MerchantId: order.MerchantId,
+				ProductId:  item.ItemId,
+				Quantity:   int(item.Quantity),
 			}
 
-			totalPrice += price * float64(item.Quantity)
-		}
-	}
-	return totalPrice, nil
-}
-
-func calculateTotalTravelTime(payload entity.OrderPayload, merchants map[string]entity.Coordinate) float64 {
-	var totalDistance float64
-	userLocation := payload.UserLocation
-	startingPointID := ""
-
-	// Identify starting point
-	for _, order := range payload.Orders {
-		if order.StartingPoint {
-			startingPointID = order.MerchantId
-			break
-		}
-	}
+			err := p.pool.QueryRow(context.Background(), query, item.ItemId, order.MerchantId).Scan(
+				&estimateItem.MerchantName, &estimateItem.MerchantCategory, &estimateItem.MerchantImageUrl,
+				&estimateItem.ProductName, &estimateItem.ProductCategory, &estimateItem.Price, &estimateItem.ProductImageUrl)
+			if err != nil {
+				return nil, errors.New("item with ID " + item.ItemId + " not found")
+			}
 
-	// Calculate distances between merchants
-	lastLocation := merchants[startingPointID]
-	for _, order := range payload.Orders {
-		if order.MerchantId != startingPointID {
-			totalDistance += haversine(lastLocation.Lat, lastLocation.Long, merchants[order.MerchantId].Lat, merchants[order.MerchantId].Long)
-			lastLocation = merchants[order.MerchantId]
+			items = append(items, estimateItem)
 		}
 	}
-
-	totalDistance += haversine(lastLocation.Lat, lastLocation.Long, userLocation.Lat, userLocation.Long)
-
-	// Convert distance to time (in minutes)
-	return totalDistance / DeliverySpeedKmPerMin
+	return items, nil
 }
 
-// Calculate total travel time using Greedy Nearest Neighbor
-func calculateTotalTravelTimeTSP(userLocation entity.Coordinate, merchants map[string]entity.Coordinate, startingPointID string) float64 {
-	merchantIDs := []string{}
-	for id := range merchants {
+// planRoute find the fastest route from the starting point through every merchant to the user.
+// It return merchant ids in visiting order, starting point first, and every leg of the route.
+func (p *purchaseHandler) planRoute(ctx context.Context, userLocation entity.Coordinate, merchants map[string]entity.Coordinate, startingPointID string) ([]string, []entity.RouteLeg, error) {
+	// the starting point is the first point and the user is the last one
+	merchantIDs := []string{startingPointID}
+	points := []routing.Point{{Lat: merchants[startingPointID].Lat, Long: merchants[startingPointID].Long}}
+	for id, location := range merchants {
 		if id != startingPointID {
 			merchantIDs = append(merchantIDs, id)
+			points = append(points, routing.Point{Lat: location.Lat, Long: location.Long})
 		}
 	}
+	points = append(points, routing.Point{Lat: userLocation.Lat, Long: userLocation.Long})
+	user := len(points) - 1
 
-	if len(merchantIDs) == 0 {
-		distance := haversine(userLocation.Lat, userLocation.Long, merchants[startingPointID].Lat, merchants[startingPointID].Long)
-		distance += haversine(merchants[startingPointID].Lat, merchants[startingPointID].Long, userLocation.Lat, userLocation.Long) // Distance back to user
-		log.Printf("Only one merchant, total distance: %.2f km", distance)
-		return distance / DeliverySpeedKmPerMin
+	matrix, err := p.router.Matrix(ctx, points)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	visited := make(map[string]bool)
-	currentLocation := merchants[startingPointID]
-	totalDistance := 0.0
-
-	for i := 0; i < len(merchantIDs); i++ {
-		nearestMerchantID := ""
-		minDistance := math.MaxFloat64
-
-		for _, id := range merchantIDs {
-			if visited[id] {
-				continue
-			}
-
-			distance := haversine(currentLocation.Lat, currentLocation.Long, merchants[id].Lat, merchants[id].Long)
-			if distance < minDistance {
-				minDistance = distance
-				nearestMerchantID = id
-			}
-		}
+	// courier come from around the user when there is only one merchant
+	path := []int{user, 0, user}
+	if len(merchantIDs) > 1 {
+		order, _ := routeplan.Plan(matrix, 0, user)
+		path = append(append([]int{0}, order...), user)
+	}
 
-		if nearestMerchantID != "" {
-			visited[nearestMerchantID] = true
-			totalDistance += minDistance
-			currentLocation = merchants[nearestMerchantID]
+	name := func(point int) string {
+		if point == user {
+			return "user"
 		}
+		return merchantIDs[point]
 	}
 
-	// Travel to the user's location after visiting all merchants
-	totalDistance += haversine(currentLocation.Lat, currentLocation.Long, userLocation.Lat, userLocation.Long)
+	route := []string{}
+	legs := []entity.RouteLeg{}
+	eta := 0.0
+	for i := 1; i < len(path); i++ {
+		from, to := path[i-1], path[i]
+		if from != user {
+			route = append(route, merchantIDs[from])
+		}
 
-	return totalDistance / DeliverySpeedKmPerMin
-}
+		eta += matrix[from][to].DurationMin
+		legs = append(legs, entity.RouteLeg{
+			From:              name(from),
+			To:                name(to),
+			DistanceKm:        round(matrix[from][to].DistanceKm),
+			DurationInMinutes: round(matrix[from][to].DurationMin),
+			EtaInMinutes:      round(eta),
+		})
+	}
 
-// Calculate Haversine distance between two points
-func haversine(lat1, lon1, lat2, lon2 float64) float64 {
-	dLat := toRadians(lat2 - lat1)
-	dLon := toRadians(lon2 - lon1)
-	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-	return EarthRadiusKm * c
+	return route, legs, nil
 }
 
-func toRadians(degree float64) float64 {
-	return degree * math.Pi / 180
-}
-
-func (p *purchaseHandler) SaveEstimate(estimateId string, payload entity.OrderPayload) {
-	p.Lock()
-	defer p.Unlock()
-
-	cacheEntimate := []CacheEstimate{}
-
-	for _, order := range payload.Orders {
-		for _, item := range order.Items {
-			temp := CacheEstimate{
-				MerchantId: order.MerchantId,
-				ProductId:  item.ItemId,
-				Qty:        int(item.Quantity),
-			}
-
-			cacheEntimate = append(cacheEntimate, temp)
-		}
-	}
-
-	p.estimate[estimateId] = cacheEntimate
+// round to 2 decimal places
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
 }
 
 // PostOrder implements PurchaseHandler.
 func (p *purchaseHandler) PostOrder(c echo.Context) error {
-	var payload struct {
-		CalculatedEstimateId string `json:"calculatedEstimateId" validate:"required"`
-	}
+	payload := &entity.PostOrderPayload{}
 
-	if err := c.Bind(&payload); err != nil {
+	if err := c.Bind(payload); err != nil {
 		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
 	}
 
@@ -342,47 +353,16 @@ func (p *purchaseHandler) PostOrder(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesnt't pass validation"))
 	}
 
-	p.Lock()
-	_, ok := p.estimate[payload.CalculatedEstimateId]
-	p.Unlock()
-
-	if !ok {
-		return c.JSON(http.StatusNotFound, exception.NotFound("calculatedEstimateId is not found"))
-	}
-
 	user := c.Get("user").(*token.JwtClaim)
 
-	orderId := ulid.Make().String()
-
-	// go p.saveOrder(user.Username, orderId, payload.CalculatedEstimateId)
-	p.saveOrder(user.Username, orderId, payload.CalculatedEstimateId)
-
-	return c.JSON(http.StatusCreated, entity.OrderResponse{
-		OrderId: orderId,
-	})
-}
-
-func (p *purchaseHandler) saveOrder(username string, orderId string, estimateId string) {
-	p.Lock()
-	defer p.Unlock()
-
-	query1 := "INSERT INTO orders(id, username) VALUES($1, $2)"
-	_, err := p.pool.Exec(context.Background(), query1, orderId, username)
+	order, err := p.pcase.PlaceOrder(c.Request().Context(), user.Username, payload)
 	if err != nil {
-		panic(err)
-	}
-
-	query2 := "INSERT INTO order_items(order_id, merchant_id, item_id, quantity) VALUES($1, $2, $3, $4)"
-
-	cacheEtimate := p.estimate[estimateId]
-
-	for _, item := range cacheEtimate {
-		_, err := p.pool.Exec(context.Background(), query2, orderId, item.MerchantId, item.ProductId,
-			item.Qty)
-		if err != nil {
-			panic(err)
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
 		}
+		panic(err)
 	}
 
-	delete(p.estimate, estimateId)
+	return c.JSON(http.StatusCreated, order)
 }
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/pkg/token"
+	"github.com/malikfajr/beli-mang/internal/usecase"
+)
+
+type reviewHandler struct {
+	reviews usecase.ReviewCase
+}
+
+func NewReviewHandler(reviews usecase.ReviewCase) *reviewHandler {
+	return &reviewHandler{
+		reviews: reviews,
+	}
+}
+
+func (r *reviewHandler) Create(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+	payload := &entity.ReviewPayload{}
+
+	if err := c.Bind(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	if err := c.Validate(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	review, err := r.reviews.Create(c.Request().Context(), user.Username, c.Param("orderId"), payload)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusCreated, review)
+}
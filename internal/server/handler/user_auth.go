@@ -12,12 +12,14 @@ import (
 )
 
 type userHandler struct {
-	pool *pgxpool.Pool
+	pool    *pgxpool.Pool
+	session usecase.SessionCase
 }
 
-func NewUserHanlder(pool *pgxpool.Pool) *userHandler {
+func NewUserHanlder(pool *pgxpool.Pool, session usecase.SessionCase) *userHandler {
 	return &userHandler{
-		pool: pool,
+		pool:    pool,
+		session: session,
 	}
 }
 
@@ -44,9 +46,16 @@ func (a *userHandler) Register(c echo.Context) error {
 		panic(err)
 	}
 
-	return c.JSON(http.StatusCreated, &entity.UserResponse{
-		Token: token.CreateToken(payload.Username, true),
-	})
+	response, err := a.session.Create(c.Request().Context(), payload.Username, token.RoleUser)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusCreated, response)
 }
 
 func (a *userHandler) Login(c echo.Context) error {
@@ -71,7 +80,51 @@ func (a *userHandler) Login(c echo.Context) error {
 		panic(err)
 	}
 
-	return c.JSON(http.StatusOK, &entity.UserResponse{
-		Token: token.CreateToken(payload.Username, true),
-	})
+	response, err := a.session.Create(c.Request().Context(), payload.Username, token.RoleUser)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (a *userHandler) Refresh(c echo.Context) error {
+	payload := &entity.RefreshPayload{}
+
+	if err := c.Bind(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn’t pass validation"))
+	}
+
+	if err := c.Validate(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn’t pass validation"))
+	}
+
+	response, err := a.session.Refresh(c.Request().Context(), payload.RefreshToken, token.RoleUser)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (a *userHandler) Logout(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+
+	if err := a.session.Revoke(c.Request().Context(), user.ID); err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.NoContent(http.StatusOK)
 }
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/pkg/token"
+	"github.com/malikfajr/beli-mang/internal/usecase"
+)
+
+type walletHandler struct {
+	wallets usecase.WalletCase
+}
+
+func NewWalletHandler(wallets usecase.WalletCase) *walletHandler {
+	return &walletHandler{
+		wallets: wallets,
+	}
+}
+
+func (w *walletHandler) Get(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+	params := &entity.WalletParams{}
+
+	c.Bind(params)
+
+	wallet, err := w.wallets.Get(c.Request().Context(), user.Username, params)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusOK, wallet)
+}
+
+func (w *walletHandler) TopUp(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+	payload := &entity.TopUpPayload{}
+
+	if err := c.Bind(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	if err := c.Validate(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	transaction, err := w.wallets.TopUp(c.Request().Context(), user.Username, payload)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusCreated, transaction)
+}
+
+func (w *walletHandler) Credit(c echo.Context) error {
+	user := c.Get("user").(*token.JwtClaim)
+	payload := &entity.CreditPayload{}
+
+	if err := c.Bind(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	if err := c.Validate(payload); err != nil {
+		return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+	}
+
+	transaction, err := w.wallets.Credit(c.Request().Context(), user.Username, c.Param("username"), payload)
+	if err != nil {
+		ex, ok := err.(*exception.CustomError)
+		if ok {
+			return c.JSON(ex.StatusCode, ex)
+		}
+		panic(err)
+	}
+
+	return c.JSON(http.StatusCreated, transaction)
+}
@@ -8,7 +8,32 @@ import (
 	jwt "github.com/malikfajr/beli-mang/internal/pkg/token"
 )
 
-func Auth(role string) echo.MiddlewareFunc {
+type RevocationChecker interface {
+	IsRevoked(jti string) bool
+}
+
+var revocation RevocationChecker
+
+// SetRevocationChecker register denylist used by Auth to reject revoked sessions
+func SetRevocationChecker(checker RevocationChecker) {
+	revocation = checker
+}
+
+// QueryToken let GET request pass access token as access_token query parameter, because EventSource and
+// WebSocket clients in browser can't set header. Use it only on streaming routes, before Auth.
+func QueryToken(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		if req.Header.Get("Authorization") == "" && req.Method == http.MethodGet && c.QueryParam("access_token") != "" {
+			req.Header.Set("Authorization", "Bearer "+c.QueryParam("access_token"))
+		}
+
+		return next(c)
+	}
+}
+
+// Auth only passing request with valid token and one of the allowed roles
+func Auth(roles ...jwt.Role) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			Authorization := c.Request().Header.Get("Authorization")
@@ -23,14 +48,16 @@ func Auth(role string) echo.MiddlewareFunc {
 				return c.JSON(http.StatusUnauthorized, exception.Unauthorized("Invalid token"))
 			}
 
-			c.Set("user", claim)
+			if revocation != nil && revocation.IsRevoked(claim.ID) {
+				return c.JSON(http.StatusUnauthorized, exception.Unauthorized("Token has been revoked"))
+			}
 
-			// check the admin role
-			if role == "admin" && claim.Admin {
-				return next(c)
+			if claim.HasRole(roles...) == false {
+				return c.JSON(http.StatusForbidden, exception.Forbidden("You don't have permission to access this resource"))
 			}
 
-			// default user passing middleware if token is valid
+			c.Set("user", claim)
+
 			return next(c)
 		}
 	}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	jwt "github.com/malikfajr/beli-mang/internal/pkg/token"
+	"github.com/malikfajr/beli-mang/internal/repository"
+)
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyKeyTTL is how long response of an idempotency key is replayed
+var IdempotencyKeyTTL = 24 * time.Hour
+
+// IdempotencyReservationTimeout is how long a request in progress hold its key, a retry after that take the key over
+// because the request is assumed to be crashed
+var IdempotencyReservationTimeout = time.Minute
+
+type bodyRecorder struct {
+	http.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (b *bodyRecorder) Write(p []byte) (int, error) {
+	b.body.Write(p)
+	return b.ResponseWriter.Write(p)
+}
+
+// Idempotency replay stored response when request is retried with the same Idempotency-Key header,
+// must be placed after Auth because the key is scoped per user
+func Idempotency(pool *pgxpool.Pool) echo.MiddlewareFunc {
+	repo := &repository.IdempotencyRepo{}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				return next(c)
+			}
+
+			if len(key) > 255 {
+				return c.JSON(http.StatusBadRequest, exception.BadRequest("Idempotency-Key max 255 characters"))
+			}
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, exception.BadRequest("request doesn't pass validation"))
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			hash := sha256.New()
+			hash.Write([]byte(c.Request().Method + " " + c.Request().URL.Path + "\n"))
+			hash.Write(body)
+			requestHash := hex.EncodeToString(hash.Sum(nil))
+
+			ctx := c.Request().Context()
+			username := c.Get("user").(*jwt.JwtClaim).Username
+
+			reservedAt, reserved, err := repo.Reserve(ctx, pool, username, key, requestHash, IdempotencyReservationTimeout)
+			if err != nil {
+				panic(err)
+			}
+
+			if reserved == false {
+				record, err := repo.Get(ctx, pool, username, key)
+				if err != nil {
+					return c.JSON(http.StatusConflict, exception.Conflict("request with the same Idempotency-Key is still processing"))
+				}
+
+				if record.RequestHash != requestHash {
+					return c.JSON(http.StatusUnprocessableEntity, exception.UnprocessableEntity("Idempotency-Key is already used with a different payload"))
+				}
+
+				if record.StatusCode == nil {
+					return c.JSON(http.StatusConflict, exception.Conflict("request with the same Idempotency-Key is still processing"))
+				}
+
+				c.Response().Header().Set("Idempotent-Replayed", "true")
+				if len(record.ResponseBody) == 0 {
+					return c.NoContent(*record.StatusCode)
+				}
+
+				return c.JSONBlob(*record.StatusCode, record.ResponseBody)
+			}
+
+			recorder := &bodyRecorder{ResponseWriter: c.Response().Writer, body: &bytes.Buffer{}}
+			c.Response().Writer = recorder
+
+			completed := false
+			defer func() {
+				// release the key when handler panic or fail, so the client can retry
+				if completed == false {
+					if err := repo.Release(context.Background(), pool, username, key, reservedAt); err != nil {
+						log.Println("cannot release idempotency key, because: ", err.Error())
+					}
+				}
+			}()
+
+			if err := next(c); err != nil {
+				return err
+			}
+
+			status := c.Response().Status
+			if status >= http.StatusInternalServerError {
+				return nil
+			}
+
+			if err := repo.Complete(context.Background(), pool, username, key, reservedAt, status, recorder.body.Bytes()); err != nil {
+				log.Println("cannot store idempotency response, because: ", err.Error())
+				return nil
+			}
+
+			completed = true
+			return nil
+		}
+	}
+}
+
+// CleanIdempotencyKeys periodically remove key older than IdempotencyKeyTTL
+func CleanIdempotencyKeys(pool *pgxpool.Pool, interval time.Duration) {
+	repo := &repository.IdempotencyRepo{}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			<-ticker.C
+			if _, err := repo.DeleteOlderThan(context.Background(), pool, time.Now().Add(-IdempotencyKeyTTL)); err != nil {
+				log.Println("cannot clean idempotency keys, because: ", err.Error())
+			}
+		}
+	}()
+}
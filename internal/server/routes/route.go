@@ -1,44 +1,153 @@
 package routes
 
 import (
+	"log"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
+	"github.com/malikfajr/beli-mang/internal/pkg/broker"
+	"github.com/malikfajr/beli-mang/internal/pkg/payment"
+	"github.com/malikfajr/beli-mang/internal/pkg/routing"
+	"github.com/malikfajr/beli-mang/internal/pkg/token"
 	"github.com/malikfajr/beli-mang/internal/server/handler"
 	"github.com/malikfajr/beli-mang/internal/server/middleware"
+	"github.com/malikfajr/beli-mang/internal/usecase"
 )
 
 func NewRoutes(e *echo.Echo, pool *pgxpool.Pool) {
-	adminHandler := handler.NewAdminHanlder(pool)
+	sessionCase := usecase.NewSessionCase(pool)
+	sessionCase.SyncDenylist(time.Minute)
+	middleware.SetRevocationChecker(sessionCase)
+
+	idempotency := middleware.Idempotency(pool)
+	middleware.CleanIdempotencyKeys(pool, time.Hour)
+
+	events := broker.New()
+
+	adminHandler := handler.NewAdminHanlder(pool, sessionCase)
 
 	admin := e.Group("/admin")
 	admin.POST("/register", adminHandler.Register)
 	admin.POST("/login", adminHandler.Login)
+	admin.POST("/refresh", adminHandler.Refresh)
+	admin.POST("/logout", adminHandler.Logout, middleware.Auth(token.RoleAdmin, token.RoleSuperAdmin))
 
-	userHandler := handler.NewUserHanlder(pool)
+	userHandler := handler.NewUserHanlder(pool, sessionCase)
 	user := e.Group("/users")
 	user.POST("/register", userHandler.Register)
 	user.POST("/login", userHandler.Login)
+	user.POST("/refresh", userHandler.Refresh)
 
-	merchantHandler := handler.NewMerchantHandler(pool)
+	merchantHandler := handler.NewMerchantHandler(pool, events)
 
-	adminMerchant := e.Group("/admin/merchants", middleware.Auth("admin"))
-	adminMerchant.POST("", merchantHandler.Create)
+	adminMerchant := e.Group("/admin/merchants", middleware.Auth(token.RoleAdmin, token.RoleSuperAdmin))
+	adminMerchant.POST("", merchantHandler.Create, idempotency)
 	adminMerchant.GET("", merchantHandler.GetAll)
+	adminMerchant.PATCH("/:merchantId", merchantHandler.Update)
+	adminMerchant.DELETE("/:merchantId", merchantHandler.Delete)
+	adminMerchant.POST("/:merchantId/restore", merchantHandler.Restore)
 	adminMerchant.POST("/:merchantId/items", merchantHandler.AddProduct)
 	adminMerchant.GET("/:merchantId/items", merchantHandler.GetProducts)
+	adminMerchant.PATCH("/:merchantId/items/:itemId", merchantHandler.UpdateProduct)
+	adminMerchant.DELETE("/:merchantId/items/:itemId", merchantHandler.DeleteProduct)
+	adminMerchant.POST("/:merchantId/items/:itemId/restore", merchantHandler.RestoreProduct)
+	adminMerchant.GET("/:merchantId/orders", merchantHandler.GetOrders)
+	adminMerchant.POST("/:merchantId/orders/:orderId/accept", merchantHandler.AcceptOrder)
+	adminMerchant.POST("/:merchantId/orders/:orderId/reject", merchantHandler.RejectOrder)
+	adminMerchant.POST("/:merchantId/orders/:orderId/ready", merchantHandler.ReadyOrder)
+	adminMerchant.POST("/:merchantId/members", merchantHandler.AddMember)
+	adminMerchant.DELETE("/:merchantId/members/:username", merchantHandler.RemoveMember)
 
-	merchantHandler.ResetCache(3 * time.Minute)
+	jwksHandler := &handler.JwksHandler{}
+	e.GET("/.well-known/jwks.json", jwksHandler.Get)
 
 	imageHandler := &handler.ImageHandler{}
-	e.POST("/image", imageHandler.Upload, middleware.Auth("admin"))
+	e.POST("/image", imageHandler.Upload, middleware.Auth(token.RoleAdmin, token.RoleSuperAdmin))
+
+	pricingCase := usecase.NewPricingCase(pool)
+	pricingHandler := handler.NewPricingHandler(pricingCase)
+
+	adminPricing := e.Group("/admin/pricing-rules", middleware.Auth(token.RoleSuperAdmin))
+	adminPricing.GET("", pricingHandler.GetAll)
+	adminPricing.PUT("/:category", pricingHandler.Save)
+	adminPricing.DELETE("/:category", pricingHandler.Delete)
+
+	promotionCase := usecase.NewPromotionCase(pool)
+	promotionHandler := handler.NewPromotionHandler(promotionCase)
+
+	adminPromotion := e.Group("/admin/promotions", middleware.Auth(token.RoleSuperAdmin))
+	adminPromotion.POST("", promotionHandler.Create)
+	adminPromotion.GET("", promotionHandler.GetAll)
+	adminPromotion.DELETE("/:code", promotionHandler.Deactivate)
+
+	provider, err := payment.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	paymentCase := usecase.NewPaymentCase(pool, provider)
+	paymentCase.Settle(time.Minute)
+	paymentHandler := handler.NewPaymentHandler(paymentCase)
+	e.POST("/payments/webhook", paymentHandler.Webhook)
+
+	walletHandler := handler.NewWalletHandler(usecase.NewWalletCase(pool, provider))
 
-	purchaseHanlder := handler.NewPurchasehandler(pool)
-	e.GET("/merchants/nearby/:coordinate", purchaseHanlder.GetMerchantNearby, middleware.Auth("user"))
+	adminWallet := e.Group("/admin/wallets", middleware.Auth(token.RoleSuperAdmin))
+	adminWallet.POST("/:username/credit", walletHandler.Credit)
 
-	userProtected := e.Group("/users", middleware.Auth("user"))
+	refundCase := usecase.NewRefundCase(pool, provider)
+	refundCase.RetryPending(time.Minute)
+	refundHandler := handler.NewRefundHandler(refundCase)
+
+	adminOrder := e.Group("/admin/orders", middleware.Auth(token.RoleSuperAdmin))
+	adminOrder.POST("/:orderId/refunds", refundHandler.Create, idempotency)
+	adminOrder.GET("/:orderId/refunds", refundHandler.GetAll)
+
+	purchaseCase := usecase.NewPurchaseCase(pool, usecase.NewEstimateStore(pool), pricingCase, promotionCase, paymentCase)
+	purchaseCase.CleanExpiredEstimate(5 * time.Minute)
+
+	router, err := routing.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	purchaseHanlder := handler.NewPurchasehandler(pool, purchaseCase, router)
+	e.GET("/merchants/nearby/:coordinate", purchaseHanlder.GetMerchantNearby, middleware.Auth(token.RoleUser))
+
+	courierAuthHandler := handler.NewCourierAuthHandler(pool, sessionCase)
+	courierCase := usecase.NewCourierCase(pool, events)
+	courierCase.AssignPending(time.Minute)
+	courierHandler := handler.NewCourierHandler(courierCase)
+
+	courier := e.Group("/couriers")
+	courier.POST("/register", courierAuthHandler.Register)
+	courier.POST("/login", courierAuthHandler.Login)
+	courier.POST("/refresh", courierAuthHandler.Refresh)
+
+	courierProtected := e.Group("/couriers", middleware.Auth(token.RoleCourier))
+	courierProtected.POST("/logout", courierAuthHandler.Logout)
+	courierProtected.PUT("/status", courierHandler.SetStatus)
+	courierProtected.POST("/location", courierHandler.UpdateLocation)
+	courierProtected.GET("/orders", courierHandler.GetOrders)
+	courierProtected.POST("/orders/:orderId/pickup", courierHandler.PickUp)
+	courierProtected.POST("/orders/:orderId/deliver", courierHandler.Deliver)
+
+	orderHandler := handler.NewOrderHandler(usecase.NewOrderCase(pool, events))
+	reviewHandler := handler.NewReviewHandler(usecase.NewReviewCase(pool))
+
+	userProtected := e.Group("/users", middleware.Auth(token.RoleUser))
 	userProtected.POST("/estimate", purchaseHanlder.CreateEstimate)
-	userProtected.POST("/orders", purchaseHanlder.PostOrder)
+	userProtected.POST("/orders", purchaseHanlder.PostOrder, idempotency)
 	userProtected.GET("/orders", purchaseHanlder.GetHistory)
+	userProtected.GET("/orders/:orderId", purchaseHanlder.GetOrder)
+	userProtected.POST("/orders/:orderId/cancel", orderHandler.Cancel)
+	userProtected.POST("/orders/:orderId/reviews", reviewHandler.Create)
+	userProtected.GET("/wallet", walletHandler.Get)
+	userProtected.POST("/wallet/topup", walletHandler.TopUp, idempotency)
+	userProtected.POST("/logout", userHandler.Logout)
+
+	orderStream := e.Group("/users/orders", middleware.QueryToken, middleware.Auth(token.RoleUser))
+	orderStream.GET("/:orderId/events", orderHandler.Events)
+	orderStream.GET("/:orderId/ws", orderHandler.Socket)
 }
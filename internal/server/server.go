@@ -4,22 +4,31 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/malikfajr/beli-mang/internal/driver/db"
 	"github.com/malikfajr/beli-mang/internal/pkg/customvalidator"
+	"github.com/malikfajr/beli-mang/internal/pkg/token"
 	"github.com/malikfajr/beli-mang/internal/server/routes"
 )
 
 func Run() {
+	if err := token.LoadKeys(); err != nil {
+		log.Fatal("Cannot load jwt keys. ", err)
+	}
+
 	e := echo.New()
 
 	e.HideBanner = true
 	e.Use(middleware.Recover())
 	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
-		Format: "method=${method}, uri=${uri}, status=${status}\n",
+		// query string is left out, it may carry access token
+		Format: "method=${method}, path=${path}, status=${status}\n",
 	}))
 	e.Use(middleware.CORS())
 
@@ -33,7 +42,25 @@ func Run() {
 
 	routes.NewRoutes(e, pool)
 
+	reloadKeysOnHangup()
+
 	if err := e.Start(":8080"); err != http.ErrServerClosed {
 		log.Fatal(err)
 	}
 }
+
+// reloadKeysOnHangup reload jwt keys on SIGHUP, used to rotate keys without restarting server
+func reloadKeysOnHangup() {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	go func() {
+		for range hangup {
+			if err := token.LoadKeys(); err != nil {
+				log.Println("cannot reload jwt keys, because: ", err.Error())
+				continue
+			}
+			log.Println("jwt keys reloaded")
+		}
+	}()
+}
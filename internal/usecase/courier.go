@@ -0,0 +1,240 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/pkg/broker"
+	"github.com/malikfajr/beli-mang/internal/repository"
+	"github.com/oklog/ulid/v2"
+)
+
+// courierStaleAfter is how long a location ping keep an online courier eligible for assignment
+const courierStaleAfter = 5 * time.Minute
+
+type CourierCase interface {
+	SetStatus(ctx context.Context, username string, status entity.CourierStatus) (*entity.Courier, error)
+	UpdateLocation(ctx context.Context, username string, payload *entity.CourierLocationPayload) error
+	GetOrders(ctx context.Context, username string) []entity.CourierOrder
+	PickUp(ctx context.Context, username string, orderId string) error
+	Deliver(ctx context.Context, username string, orderId string) error
+	AssignPending(interval time.Duration)
+}
+
+type courierCase struct {
+	pool   *pgxpool.Pool
+	crepo  *repository.CourierRepo
+	orepo  *repository.OrderRepo
+	events broker.Broker
+}
+
+func NewCourierCase(pool *pgxpool.Pool, events broker.Broker) CourierCase {
+	return &courierCase{
+		pool:   pool,
+		crepo:  &repository.CourierRepo{},
+		orepo:  &repository.OrderRepo{},
+		events: events,
+	}
+}
+
+// SetStatus put courier online or offline, busy courier must deliver the order first
+func (c *courierCase) SetStatus(ctx context.Context, username string, status entity.CourierStatus) (*entity.Courier, error) {
+	tx, err := c.pool.Begin(ctx)
+	if err != nil {
+		return nil, exception.ServerError(err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	courier, err := c.crepo.GetForUpdateTx(ctx, tx, username)
+	if err != nil {
+		return nil, exception.NotFound("courier not found")
+	}
+
+	if courier.Status == entity.CourierBusy {
+		return nil, exception.Conflict("courier is delivering an order")
+	}
+
+	if err := c.crepo.SetStatusTx(ctx, tx, username, status); err != nil {
+		return nil, exception.ServerError(err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, exception.ServerError(err.Error())
+	}
+
+	courier.Status = status
+	return courier, nil
+}
+
+// UpdateLocation save location ping of the courier and forward it to subscribers of orders handled by the courier
+func (c *courierCase) UpdateLocation(ctx context.Context, username string, payload *entity.CourierLocationPayload) error {
+	if err := c.crepo.UpdateLocation(ctx, c.pool, username, payload.Lat, payload.Long); err != nil {
+		return exception.NotFound("courier not found")
+	}
+
+	for _, order := range c.crepo.GetActiveOrders(ctx, c.pool, username) {
+		c.events.Publish(broker.OrderTopic(order.OrderId), broker.Event{
+			Type:    broker.EventCourierLocation,
+			OrderId: order.OrderId,
+			Data: &entity.CourierLocation{
+				Username: username,
+				Lat:      payload.Lat,
+				Long:     payload.Long,
+			},
+		})
+	}
+
+	return nil
+}
+
+func (c *courierCase) GetOrders(ctx context.Context, username string) []entity.CourierOrder {
+	return c.crepo.GetActiveOrders(ctx, c.pool, username)
+}
+
+// PickUp order from merchants, every merchant which accept the order must have marked it ready
+func (c *courierCase) PickUp(ctx context.Context, username string, orderId string) error {
+	return c.handOver(ctx, username, orderId, entity.OrderPickedUp, func(tx pgx.Tx) error {
+		tickets, err := c.orepo.GetTicketStatusesTx(ctx, tx, orderId)
+		if err != nil {
+			return exception.ServerError(err.Error())
+		}
+
+		for _, ticket := range tickets {
+			if ticket == entity.TicketPending || ticket == entity.TicketAccepted {
+				return exception.Conflict("order is not ready to pick up")
+			}
+		}
+
+		return nil
+	})
+}
+
+// Deliver order to user, courier is available for the next order afterwards
+func (c *courierCase) Deliver(ctx context.Context, username string, orderId string) error {
+	return c.handOver(ctx, username, orderId, entity.OrderDelivered, func(tx pgx.Tx) error {
+		if err := c.crepo.SetStatusTx(ctx, tx, username, entity.CourierOnline); err != nil {
+			return exception.ServerError(err.Error())
+		}
+
+		return nil
+	})
+}
+
+// handOver move order assigned to the courier to the next status, check run in the same transaction
+func (c *courierCase) handOver(ctx context.Context, username string, orderId string, to entity.OrderStatus, check func(tx pgx.Tx) error) error {
+	if _, err := ulid.Parse(orderId); err != nil {
+		return exception.NotFound("orderId not found")
+	}
+
+	tx, err := c.pool.Begin(ctx)
+	if err != nil {
+		return exception.ServerError(err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := c.orepo.GetStatusForUpdateTx(ctx, tx, orderId); err != nil {
+		return exception.NotFound("orderId not found")
+	}
+
+	courier, err := c.orepo.GetCourierTx(ctx, tx, orderId)
+	if err != nil || courier != username {
+		return exception.NotFound("orderId not found")
+	}
+
+	if err := check(tx); err != nil {
+		return err
+	}
+
+	history, err := transitionOrderTx(ctx, tx, orderId, to, username, "")
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return exception.ServerError(err.Error())
+	}
+
+	publishStatus(c.events, orderId, *history)
+
+	return nil
+}
+
+// AssignPending periodically retry assignment of accepted orders which found no courier before
+func (c *courierCase) AssignPending(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			<-ticker.C
+			ids, err := c.orepo.GetUnassigned(context.Background(), c.pool, 20)
+			if err != nil {
+				log.Println("cannot get unassigned order, because: ", err.Error())
+				continue
+			}
+
+			for _, id := range ids {
+				if err := c.assign(context.Background(), id); err != nil {
+					log.Println("cannot assign courier to order, because: ", err.Error())
+				}
+			}
+		}
+	}()
+}
+
+func (c *courierCase) assign(ctx context.Context, orderId string) error {
+	tx, err := c.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := c.orepo.GetStatusForUpdateTx(ctx, tx, orderId)
+	if err != nil {
+		return err
+	}
+
+	// order may have moved on since it was listed
+	if status != entity.OrderAccepted && status != entity.OrderPreparing {
+		return nil
+	}
+
+	if err := assignCourierTx(ctx, tx, orderId); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// assignCourierTx give the order to the nearest online courier from the starting point merchant.
+// Order is left unassigned when no courier is available, AssignPending will retry it later.
+func assignCourierTx(ctx context.Context, tx pgx.Tx, orderId string) error {
+	orderRepo := &repository.OrderRepo{}
+	courierRepo := &repository.CourierRepo{}
+
+	if courier, err := orderRepo.GetCourierTx(ctx, tx, orderId); err != nil || courier != "" {
+		return err
+	}
+
+	start, err := orderRepo.GetStartingPointTx(ctx, tx, orderId)
+	if err != nil {
+		// order placed before starting point is recorded
+		return nil
+	}
+
+	username, err := courierRepo.FindNearestAvailableTx(ctx, tx, start.Lat, start.Long, time.Now().Add(-courierStaleAfter))
+	if err != nil {
+		return nil
+	}
+
+	if err := orderRepo.AssignCourierTx(ctx, tx, orderId, username); err != nil {
+		return err
+	}
+
+	return courierRepo.SetStatusTx(ctx, tx, username, entity.CourierBusy)
+}
@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/pkg/password"
+	"github.com/malikfajr/beli-mang/internal/repository"
+)
+
+type courierAuth struct {
+	pool *pgxpool.Pool
+}
+
+func NewCourierAuth(pool *pgxpool.Pool) *courierAuth {
+	return &courierAuth{
+		pool: pool,
+	}
+}
+
+func (a *courierAuth) Insert(ctx context.Context, payload *entity.User) error {
+	payload.Password = password.Hash(payload.Password)
+
+	tx, err := a.pool.Begin(ctx)
+	if err != nil {
+		panic(err)
+	}
+	defer tx.Rollback(ctx)
+
+	courierRepo := &repository.CourierRepo{}
+	if exist := courierRepo.EmailExistTx(ctx, tx, payload.Email); exist == true {
+		return exception.Conflict("Email is exists")
+	}
+
+	if err := courierRepo.InsertTx(ctx, tx, payload); err != nil {
+		return exception.Conflict("Username is exists")
+	}
+
+	tx.Commit(ctx)
+	return nil
+}
+
+func (a *courierAuth) Login(ctx context.Context, payload *entity.UserLogin) (*entity.User, error) {
+	courierRepo := &repository.CourierRepo{}
+
+	user, err := courierRepo.GetByUsername(ctx, a.pool, payload.Username)
+	if err != nil {
+		return nil, exception.BadRequest("request doesn’t pass validation / password is wrong")
+	}
+
+	if password.Compare(user.Password, payload.Password) == false {
+		return nil, exception.BadRequest("request doesn’t pass validation / password is wrong")
+	}
+
+	return user, nil
+}
@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oklog/ulid/v2"
+)
+
+// testPool connect to TEST_DATABASE_URL and migrate a fresh schema which is dropped after the test
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	schema := "test_" + strings.ToLower(ulid.Make().String())
+
+	conn, err := pgx.Connect(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(ctx)
+
+	if _, err := conn.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = schema
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		pool.Close()
+
+		conn, err := pgx.Connect(context.Background(), url)
+		if err != nil {
+			t.Log("cannot drop test schema: ", err)
+			return
+		}
+		defer conn.Close(context.Background())
+
+		conn.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+	})
+
+	files, err := filepath.Glob("../../db/migrations/*.up.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatal("cannot find migrations", err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		migration, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := pool.Exec(ctx, string(migration)); err != nil {
+			t.Fatalf("cannot apply %s: %v", filepath.Base(file), err)
+		}
+	}
+
+	return pool
+}
+
+// seedMerchant create a merchant with one product, returning their id
+func seedMerchant(t *testing.T, pool *pgxpool.Pool, admin string, price int) (string, string) {
+	t.Helper()
+
+	ctx := context.Background()
+	merchantId, productId := ulid.Make().String(), ulid.Make().String()
+
+	seedUser(t, pool, admin, true)
+
+	_, err := pool.Exec(ctx, `INSERT INTO merchants(id, username_admin, name, category, image_url, lat, long, geohash)
+		VALUES($1, $2, 'Warung Test', 'SmallRestaurant', 'https://example.com/m.jpg', -6.2, 106.8, 'qqguw')`, merchantId, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = pool.Exec(ctx, `INSERT INTO products(id, merchant_id, name, category, price, image_url)
+		VALUES($1, $2, 'Nasi Goreng', 'Food', $3, 'https://example.com/p.jpg')`, productId, merchantId, price)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return merchantId, productId
+}
+
+func seedUser(t *testing.T, pool *pgxpool.Pool, username string, admin bool) {
+	t.Helper()
+
+	_, err := pool.Exec(context.Background(), "INSERT INTO users(username, password, email, admin) VALUES($1, $2, $3, $4)",
+		username, strings.Repeat("x", 60), username+"@example.com", admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,188 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/repository"
+)
+
+var (
+	ErrEstimateNotFound  = errors.New("estimate not found")
+	ErrEstimateExpired   = errors.New("estimate is expired")
+	ErrEstimateForbidden = errors.New("estimate belongs to another user")
+)
+
+// EstimateStore keep calculated estimate until it is ordered or expired
+type EstimateStore interface {
+	Save(ctx context.Context, estimate *entity.Estimate) error
+	// Get return estimate owned by username which is not expired, the estimate is kept in the store
+	Get(ctx context.Context, estimateId string, username string) (*entity.Estimate, error)
+	// ConsumeTx atomically remove and return estimate owned by username as part of tx, an estimate can be consumed
+	// only once. It is gone only when tx is committed, except in memory store which can't join tx.
+	ConsumeTx(ctx context.Context, tx pgx.Tx, estimateId string, username string) (*entity.Estimate, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// NewEstimateStore select store from ESTIMATE_STORE env, "memory" only for single instance / local dev
+func NewEstimateStore(pool *pgxpool.Pool) EstimateStore {
+	if os.Getenv("ESTIMATE_STORE") == "memory" {
+		return NewMemoryEstimateStore()
+	}
+
+	return NewPostgresEstimateStore(pool)
+}
+
+// EstimateTTL read ESTIMATE_TTL env, default is 30 minutes
+func EstimateTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("ESTIMATE_TTL"))
+	if err != nil || ttl <= 0 {
+		return 30 * time.Minute
+	}
+
+	return ttl
+}
+
+type postgresEstimateStore struct {
+	pool  *pgxpool.Pool
+	erepo *repository.EstimateRepo
+}
+
+func NewPostgresEstimateStore(pool *pgxpool.Pool) EstimateStore {
+	return &postgresEstimateStore{
+		pool:  pool,
+		erepo: &repository.EstimateRepo{},
+	}
+}
+
+func (p *postgresEstimateStore) Save(ctx context.Context, estimate *entity.Estimate) error {
+	return p.erepo.Insert(ctx, p.pool, estimate)
+}
+
+func (p *postgresEstimateStore) Get(ctx context.Context, estimateId string, username string) (*entity.Estimate, error) {
+	estimate, err := p.erepo.GetActive(ctx, p.pool, estimateId, username)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, p.unavailable(ctx, estimateId, username)
+	}
+
+	return estimate, err
+}
+
+func (p *postgresEstimateStore) ConsumeTx(ctx context.Context, tx pgx.Tx, estimateId string, username string) (*entity.Estimate, error) {
+	estimate, err := p.erepo.ConsumeTx(ctx, tx, estimateId, username)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, p.unavailable(ctx, estimateId, username)
+	}
+
+	return estimate, err
+}
+
+// unavailable find out why the estimate didn't match. Expiry was decided by database clock of the query,
+// so an existing estimate of the user is expired, or consumed meanwhile.
+func (p *postgresEstimateStore) unavailable(ctx context.Context, estimateId string, username string) error {
+	owner, err := p.erepo.GetOwner(ctx, p.pool, estimateId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrEstimateNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if owner != username {
+		return ErrEstimateForbidden
+	}
+
+	return ErrEstimateExpired
+}
+
+func (p *postgresEstimateStore) DeleteExpired(ctx context.Context) (int64, error) {
+	return p.erepo.DeleteExpired(ctx, p.pool)
+}
+
+type memoryEstimateStore struct {
+	estimate map[string]*entity.Estimate
+	sync.Mutex
+}
+
+func NewMemoryEstimateStore() EstimateStore {
+	return &memoryEstimateStore{
+		estimate: make(map[string]*entity.Estimate),
+	}
+}
+
+func (m *memoryEstimateStore) Save(ctx context.Context, estimate *entity.Estimate) error {
+	m.Lock()
+	defer m.Unlock()
+
+	m.estimate[estimate.Id] = estimate
+	return nil
+}
+
+func (m *memoryEstimateStore) Get(ctx context.Context, estimateId string, username string) (*entity.Estimate, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	estimate, ok := m.estimate[estimateId]
+	if ok == false {
+		return nil, ErrEstimateNotFound
+	}
+
+	if err := checkEstimate(estimate, username); err != nil {
+		return nil, err
+	}
+
+	return estimate, nil
+}
+
+// ConsumeTx remove the estimate right away, it is not given back when tx is rolled back
+func (m *memoryEstimateStore) ConsumeTx(ctx context.Context, tx pgx.Tx, estimateId string, username string) (*entity.Estimate, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	estimate, ok := m.estimate[estimateId]
+	if ok == false {
+		return nil, ErrEstimateNotFound
+	}
+
+	if err := checkEstimate(estimate, username); err != nil {
+		return nil, err
+	}
+
+	delete(m.estimate, estimateId)
+	return estimate, nil
+}
+
+// checkEstimate return reason why estimate can't be used by username
+func checkEstimate(estimate *entity.Estimate, username string) error {
+	if estimate.Username != username {
+		return ErrEstimateForbidden
+	}
+
+	if estimate.ExpiresAt.After(time.Now()) == false {
+		return ErrEstimateExpired
+	}
+
+	return nil
+}
+
+func (m *memoryEstimateStore) DeleteExpired(ctx context.Context) (int64, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	var total int64
+	now := time.Now()
+	for id, estimate := range m.estimate {
+		if estimate.ExpiresAt.Before(now) {
+			delete(m.estimate, id)
+			total++
+		}
+	}
+
+	return total, nil
+}
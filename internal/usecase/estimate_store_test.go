@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/oklog/ulid/v2"
+)
+
+// testEstimateStore check the store, begin return transaction to consume in or nil for memory store
+func testEstimateStore(t *testing.T, store EstimateStore, begin func() pgx.Tx) {
+	ctx := context.Background()
+
+	save := func(username string, expiresAt time.Time) string {
+		t.Helper()
+
+		estimate := &entity.Estimate{
+			Id:         ulid.Make().String(),
+			Username:   username,
+			TotalPrice: 30000,
+			Items:      []entity.EstimateItem{},
+			ExpiresAt:  expiresAt,
+		}
+		if err := store.Save(ctx, estimate); err != nil {
+			t.Fatal(err)
+		}
+
+		return estimate.Id
+	}
+
+	active := save("alice", time.Now().Add(time.Minute))
+	expired := save("alice", time.Now().Add(-time.Minute))
+
+	tests := []struct {
+		name       string
+		estimateId string
+		username   string
+		want       error
+	}{
+		{"another user", active, "bob", ErrEstimateForbidden},
+		{"expired", expired, "alice", ErrEstimateExpired},
+		{"unknown", ulid.Make().String(), "alice", ErrEstimateNotFound},
+		{"owner", active, "alice", nil},
+	}
+
+	for _, tt := range tests {
+		estimate, err := store.Get(ctx, tt.estimateId, tt.username)
+		if err != tt.want {
+			t.Fatalf("%s: got error %v, want %v", tt.name, err, tt.want)
+		}
+
+		// an estimate is returned exactly when there is no error
+		if (estimate == nil) == (err == nil) {
+			t.Fatalf("%s: got estimate %v with error %v", tt.name, estimate, err)
+		}
+	}
+
+	if tx := begin(); tx != nil {
+		if _, err := store.ConsumeTx(ctx, tx, active, "alice"); err != nil {
+			t.Fatal(err)
+		}
+		tx.Rollback(ctx)
+
+		if _, err := store.Get(ctx, active, "alice"); err != nil {
+			t.Fatalf("estimate consumed by rolled back transaction is gone: %v", err)
+		}
+	}
+
+	consume := func() error {
+		t.Helper()
+
+		tx := begin()
+		estimate, err := store.ConsumeTx(ctx, tx, active, "alice")
+		if tx != nil {
+			if err := tx.Commit(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if (estimate == nil) == (err == nil) {
+			t.Fatalf("got estimate %v with error %v", estimate, err)
+		}
+
+		return err
+	}
+
+	if err := consume(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := consume(); err != ErrEstimateNotFound {
+		t.Fatalf("consumed twice, got error %v, want %v", err, ErrEstimateNotFound)
+	}
+}
+
+func TestMemoryEstimateStore(t *testing.T) {
+	testEstimateStore(t, NewMemoryEstimateStore(), func() pgx.Tx { return nil })
+}
+
+func TestPostgresEstimateStore(t *testing.T) {
+	pool := testPool(t)
+	seedUser(t, pool, "alice", false)
+
+	testEstimateStore(t, NewPostgresEstimateStore(pool), func() pgx.Tx {
+		tx, err := pool.Begin(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return tx
+	})
+}
@@ -2,33 +2,43 @@ package usecase
 
 import (
 	"context"
-	"sync"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/malikfajr/beli-mang/internal/entity"
 	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/pkg/broker"
+	"github.com/malikfajr/beli-mang/internal/pkg/token"
 	"github.com/malikfajr/beli-mang/internal/repository"
 	"github.com/oklog/ulid/v2"
 )
 
 type manageMerchant struct {
-	pool *pgxpool.Pool
-	id   map[string]bool
-	sync.Mutex
+	pool   *pgxpool.Pool
+	events broker.Broker
 }
 
 type ManageMerchant interface {
 	Create(ctx context.Context, username string, payload *entity.AddMerchantPayload) (*entity.Merchant, error)
-	GetAll(ctx context.Context, username string, params *entity.MerchantParams) (*[]entity.Merchant, int, error)
-	AddProduct(ctx context.Context, merchantId string, payload *entity.AddProductPayload) (*entity.Product, error)
-	GetProducts(ctx context.Context, username string, params *entity.ProductParams) (*[]entity.Product, int, error)
-	ResetData()
+	GetAll(ctx context.Context, user *token.JwtClaim, params *entity.MerchantParams) (*[]entity.Merchant, int, error)
+	Update(ctx context.Context, user *token.JwtClaim, merchantId string, payload *entity.UpdateMerchantPayload) (*entity.Merchant, error)
+	Delete(ctx context.Context, user *token.JwtClaim, merchantId string) error
+	Restore(ctx context.Context, user *token.JwtClaim, merchantId string) error
+	AddProduct(ctx context.Context, user *token.JwtClaim, merchantId string, payload *entity.AddProductPayload) (*entity.Product, error)
+	GetProducts(ctx context.Context, user *token.JwtClaim, params *entity.ProductParams) (*[]entity.Product, int, error)
+	UpdateProduct(ctx context.Context, user *token.JwtClaim, merchantId string, productId string, payload *entity.UpdateProductPayload) (*entity.Product, error)
+	DeleteProduct(ctx context.Context, user *token.JwtClaim, merchantId string, productId string) error
+	RestoreProduct(ctx context.Context, user *token.JwtClaim, merchantId string, productId string) error
+	GetOrders(ctx context.Context, user *token.JwtClaim, params *entity.MerchantTicketParams) (*[]entity.MerchantTicket, int, error)
+	UpdateOrder(ctx context.Context, user *token.JwtClaim, merchantId string, orderId string, status entity.TicketStatus, reason string) error
+	AddMember(ctx context.Context, user *token.JwtClaim, merchantId string, payload *entity.AddMemberPayload) error
+	RemoveMember(ctx context.Context, user *token.JwtClaim, merchantId string, username string) error
 }
 
-func NewManageMerchant(pool *pgxpool.Pool) ManageMerchant {
+func NewManageMerchant(pool *pgxpool.Pool, events broker.Broker) ManageMerchant {
 	return &manageMerchant{
-		pool: pool,
-		id:   make(map[string]bool),
+		pool:   pool,
+		events: events,
 	}
 }
 
@@ -50,14 +60,10 @@ func (m *manageMerchant) Create(ctx context.Context, username string, payload *e
 		return nil, exception.ServerError(err.Error())
 	}
 
-	m.Lock()
-	defer m.Unlock()
-	m.id[merchant.Id] = true
-
 	return merchant, nil
 }
 
-func (m *manageMerchant) GetAll(ctx context.Context, username string, params *entity.MerchantParams) (*[]entity.Merchant, int, error) {
+func (m *manageMerchant) GetAll(ctx context.Context, user *token.JwtClaim, params *entity.MerchantParams) (*[]entity.Merchant, int, error) {
 	if params.Limit == 0 {
 		params.Limit = 5
 	}
@@ -66,6 +72,12 @@ func (m *manageMerchant) GetAll(ctx context.Context, username string, params *en
 		params.CreatedAt = ""
 	}
 
+	// super admin see every merchant
+	username := user.Username
+	if user.Role == token.RoleSuperAdmin {
+		username = ""
+	}
+
 	merchantRepo := &repository.MerchantRepo{}
 	merchants := merchantRepo.GetAll(ctx, m.pool, username, params)
 	total := merchantRepo.GetTotalMerchant(ctx, m.pool, username, params)
@@ -73,8 +85,77 @@ func (m *manageMerchant) GetAll(ctx context.Context, username string, params *en
 	return &merchants, total, nil
 }
 
-func (m *manageMerchant) AddProduct(ctx context.Context, merchantId string, payload *entity.AddProductPayload) (*entity.Product, error) {
-	if err := m.isFound(merchantId); err != nil {
+func (m *manageMerchant) Update(ctx context.Context, user *token.JwtClaim, merchantId string, payload *entity.UpdateMerchantPayload) (*entity.Merchant, error) {
+	if err := m.canManage(ctx, user, merchantId); err != nil {
+		return nil, err
+	}
+
+	merchantRepo := &repository.MerchantRepo{}
+	merchant, err := merchantRepo.GetById(ctx, m.pool, merchantId)
+	if err != nil {
+		return nil, exception.NotFound("merchantId not found")
+	}
+
+	if payload.Name != nil {
+		merchant.Name = *payload.Name
+	}
+
+	if payload.Category != nil {
+		merchant.Category = *payload.Category
+	}
+
+	if payload.ImageUrl != nil {
+		merchant.ImageUrl = *payload.ImageUrl
+	}
+
+	if payload.Location != nil {
+		merchant.Location = payload.Location
+	}
+
+	if err := merchantRepo.Update(ctx, m.pool, merchant); err != nil {
+		return nil, exception.NotFound("merchantId not found")
+	}
+
+	return merchant, nil
+}
+
+func (m *manageMerchant) Delete(ctx context.Context, user *token.JwtClaim, merchantId string) error {
+	if err := m.isOwner(ctx, user, merchantId); err != nil {
+		return err
+	}
+
+	merchantRepo := &repository.MerchantRepo{}
+	if err := merchantRepo.Delete(ctx, m.pool, merchantId); err != nil {
+		return exception.NotFound("merchantId not found")
+	}
+
+	return nil
+}
+
+func (m *manageMerchant) Restore(ctx context.Context, user *token.JwtClaim, merchantId string) error {
+	if _, err := ulid.Parse(merchantId); err != nil {
+		return exception.NotFound("merchantId not found")
+	}
+
+	merchantRepo := &repository.MerchantRepo{}
+	merchant, err := merchantRepo.GetDeletedById(ctx, m.pool, merchantId)
+	if err != nil {
+		return exception.NotFound("merchantId not found")
+	}
+
+	if user.Role != token.RoleSuperAdmin && merchant.Username != user.Username {
+		return exception.NotFound("merchantId not found")
+	}
+
+	if err := merchantRepo.Restore(ctx, m.pool, merchantId); err != nil {
+		return exception.NotFound("merchantId not found")
+	}
+
+	return nil
+}
+
+func (m *manageMerchant) AddProduct(ctx context.Context, user *token.JwtClaim, merchantId string, payload *entity.AddProductPayload) (*entity.Product, error) {
+	if err := m.canManage(ctx, user, merchantId); err != nil {
 		return nil, err
 	}
 
@@ -96,8 +177,8 @@ func (m *manageMerchant) AddProduct(ctx context.Context, merchantId string, payl
 	return product, nil
 }
 
-func (m *manageMerchant) GetProducts(ctx context.Context, username string, params *entity.ProductParams) (*[]entity.Product, int, error) {
-	if err := m.isFound(params.MerchantId); err != nil {
+func (m *manageMerchant) GetProducts(ctx context.Context, user *token.JwtClaim, params *entity.ProductParams) (*[]entity.Product, int, error) {
+	if err := m.canManage(ctx, user, params.MerchantId); err != nil {
 		return nil, 0, err
 	}
 
@@ -116,35 +197,246 @@ func (m *manageMerchant) GetProducts(ctx context.Context, username string, param
 	return &products, total, nil
 }
 
-func (m *manageMerchant) isFound(merchantId string) error {
-	_, err := ulid.Parse(merchantId)
+func (m *manageMerchant) UpdateProduct(ctx context.Context, user *token.JwtClaim, merchantId string, productId string, payload *entity.UpdateProductPayload) (*entity.Product, error) {
+	if err := m.canManage(ctx, user, merchantId); err != nil {
+		return nil, err
+	}
+
+	merchantRepo := &repository.MerchantRepo{}
+	product, err := merchantRepo.GetProductById(ctx, m.pool, merchantId, productId)
+	if err != nil {
+		return nil, exception.NotFound("itemId not found")
+	}
+
+	if payload.Name != nil {
+		product.Name = *payload.Name
+	}
+
+	if payload.Category != nil {
+		product.Category = *payload.Category
+	}
+
+	if payload.Price != nil {
+		product.Price = *payload.Price
+	}
+
+	if payload.ImageUrl != nil {
+		product.ImageUrl = *payload.ImageUrl
+	}
+
+	if err := merchantRepo.UpdateProduct(ctx, m.pool, product); err != nil {
+		return nil, exception.NotFound("itemId not found")
+	}
+
+	return product, nil
+}
+
+func (m *manageMerchant) DeleteProduct(ctx context.Context, user *token.JwtClaim, merchantId string, productId string) error {
+	if err := m.canManage(ctx, user, merchantId); err != nil {
+		return err
+	}
+
+	merchantRepo := &repository.MerchantRepo{}
+	if err := merchantRepo.DeleteProduct(ctx, m.pool, merchantId, productId); err != nil {
+		return exception.NotFound("itemId not found")
+	}
+
+	return nil
+}
+
+func (m *manageMerchant) RestoreProduct(ctx context.Context, user *token.JwtClaim, merchantId string, productId string) error {
+	if err := m.canManage(ctx, user, merchantId); err != nil {
+		return err
+	}
+
+	merchantRepo := &repository.MerchantRepo{}
+	if err := merchantRepo.RestoreProduct(ctx, m.pool, merchantId, productId); err != nil {
+		return exception.NotFound("itemId not found")
+	}
+
+	return nil
+}
+
+// GetOrders return order queue of a merchant, each order only contain items of the merchant
+func (m *manageMerchant) GetOrders(ctx context.Context, user *token.JwtClaim, params *entity.MerchantTicketParams) (*[]entity.MerchantTicket, int, error) {
+	if err := m.canManage(ctx, user, params.MerchantId); err != nil {
+		return nil, 0, err
+	}
+
+	if params.Limit <= 0 {
+		params.Limit = 5
+	}
+
+	if params.Status != "" && validTicketStatus(params.Status) == false {
+		return nil, 0, exception.BadRequest("status not valid")
+	}
+
+	from, err := parseDate(params.From)
+	if err != nil {
+		return nil, 0, exception.BadRequest("from not valid")
+	}
+
+	to, err := parseEndDate(params.To)
+	if err != nil {
+		return nil, 0, exception.BadRequest("to not valid")
+	}
+
+	orderRepo := &repository.OrderRepo{}
+	tickets := orderRepo.GetMerchantTickets(ctx, m.pool, params, from, to)
+	total := orderRepo.TotalMerchantTickets(ctx, m.pool, params, from, to)
+
+	return &tickets, total, nil
+}
+
+// UpdateOrder accept, reject or mark ready the part of order handled by the merchant
+func (m *manageMerchant) UpdateOrder(ctx context.Context, user *token.JwtClaim, merchantId string, orderId string, status entity.TicketStatus, reason string) error {
+	if err := m.canManage(ctx, user, merchantId); err != nil {
+		return err
+	}
+
+	if _, err := ulid.Parse(orderId); err != nil {
+		return exception.NotFound("orderId not found")
+	}
+
+	tx, err := m.pool.Begin(ctx)
 	if err != nil {
+		return exception.ServerError(err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	changes, err := updateTicketTx(ctx, tx, orderId, merchantId, status, user.Username, reason)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return exception.ServerError(err.Error())
+	}
+
+	m.events.Publish(broker.OrderTopic(orderId), broker.Event{
+		Type:    broker.EventTicket,
+		OrderId: orderId,
+		Data: &entity.TicketUpdate{
+			MerchantId: merchantId,
+			Status:     status,
+			Reason:     reason,
+		},
+	})
+	publishStatus(m.events, orderId, changes...)
+
+	return nil
+}
+
+func (m *manageMerchant) AddMember(ctx context.Context, user *token.JwtClaim, merchantId string, payload *entity.AddMemberPayload) error {
+	if err := m.isOwner(ctx, user, merchantId); err != nil {
+		return err
+	}
+
+	adminRepo := &repository.AdminRepo{}
+	if _, err := adminRepo.GetByUsername(ctx, m.pool, payload.Username); err != nil {
+		return exception.NotFound("admin not found")
+	}
+
+	merchantRepo := &repository.MerchantRepo{}
+	if err := merchantRepo.AddMember(ctx, m.pool, merchantId, payload.Username); err != nil {
+		return exception.Conflict(err.Error())
+	}
+
+	return nil
+}
+
+func (m *manageMerchant) RemoveMember(ctx context.Context, user *token.JwtClaim, merchantId string, username string) error {
+	if err := m.isOwner(ctx, user, merchantId); err != nil {
+		return err
+	}
+
+	merchantRepo := &repository.MerchantRepo{}
+	if err := merchantRepo.DeleteMember(ctx, m.pool, merchantId, username); err != nil {
+		return exception.NotFound(err.Error())
+	}
+
+	return nil
+}
+
+// isOwner only owner and super admin can manage members of merchant
+func (m *manageMerchant) isOwner(ctx context.Context, user *token.JwtClaim, merchantId string) error {
+	if _, err := ulid.Parse(merchantId); err != nil {
 		return exception.NotFound("merchantId not found")
 	}
 
-	m.Lock()
-	defer m.Unlock()
+	merchantRepo := &repository.MerchantRepo{}
+	merchant, err := merchantRepo.GetById(ctx, m.pool, merchantId)
+	if err != nil {
+		return exception.NotFound("merchantId not found")
+	}
 
-	if _, ok := m.id[merchantId]; ok {
-		return nil
+	if user.Role != token.RoleSuperAdmin && merchant.Username != user.Username {
+		return exception.Forbidden("only owner of merchant can manage members")
 	}
 
-	merchanRepo := &repository.MerchantRepo{}
-	merchant, err := merchanRepo.GetById(context.Background(), m.pool, merchantId)
+	return nil
+}
+
+// canManage check merchant is exist and managed by user, merchant of other admin is treated as not found.
+// Membership is read on every request, so a removed member lose access right away on every instance.
+func (m *manageMerchant) canManage(ctx context.Context, user *token.JwtClaim, merchantId string) error {
+	_, err := ulid.Parse(merchantId)
 	if err != nil {
 		return exception.NotFound("merchantId not found")
 	}
 
-	m.id[merchant.Id] = true
+	merchantRepo := &repository.MerchantRepo{}
+	if merchantRepo.CanManage(ctx, m.pool, merchantId, user.Username, user.Role == token.RoleSuperAdmin) == false {
+		return exception.NotFound("merchantId not found")
+	}
 
 	return nil
 }
 
-func (m *manageMerchant) ResetData() {
-	m.Lock()
-	defer m.Unlock()
+func validTicketStatus(key string) bool {
+	statuses := map[string]bool{
+		string(entity.TicketPending):   true,
+		string(entity.TicketAccepted):  true,
+		string(entity.TicketReady):     true,
+		string(entity.TicketRejected):  true,
+		string(entity.TicketCancelled): true,
+	}
+
+	_, ok := statuses[key]
+	return ok
+}
+
+// parseDate accept RFC3339 or YYYY-MM-DD, empty value return nil
+func parseDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// parseEndDate is parseDate for an exclusive upper bound, YYYY-MM-DD include the whole day
+func parseEndDate(value string) (*time.Time, error) {
+	t, err := parseDate(value)
+	if err != nil || t == nil {
+		return t, err
+	}
+
+	if _, err := time.Parse(time.DateOnly, value); err == nil {
+		end := t.AddDate(0, 0, 1)
+		return &end, nil
+	}
 
-	m.id = make(map[string]bool)
+	return t, nil
 }
 
 func validOrder(key string) bool {
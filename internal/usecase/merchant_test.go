@@ -0,0 +1,42 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseEndDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"2024-06-10", "2024-06-11T00:00:00Z"},
+		{"2024-06-30", "2024-07-01T00:00:00Z"},
+		{"2024-06-10T15:04:05Z", "2024-06-10T15:04:05Z"},
+		{"2024-06-10T15:04:05+07:00", "2024-06-10T15:04:05+07:00"},
+	}
+
+	for _, tt := range tests {
+		got, err := parseEndDate(tt.value)
+		if err != nil {
+			t.Errorf("parseEndDate(%q) error %v", tt.value, err)
+			continue
+		}
+
+		if tt.want == "" {
+			if got != nil {
+				t.Errorf("parseEndDate(%q) = %v, want nil", tt.value, got)
+			}
+			continue
+		}
+
+		if got == nil || got.Format(time.RFC3339) != tt.want {
+			t.Errorf("parseEndDate(%q) = %v, want %s", tt.value, got, tt.want)
+		}
+	}
+
+	if _, err := parseEndDate("10-06-2024"); err == nil {
+		t.Error("parseEndDate accept invalid date")
+	}
+}
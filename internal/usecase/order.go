@@ -0,0 +1,279 @@
+package usecase
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/pkg/broker"
+	"github.com/malikfajr/beli-mang/internal/repository"
+	"github.com/oklog/ulid/v2"
+)
+
+type OrderCase interface {
+	Transition(ctx context.Context, orderId string, to entity.OrderStatus, actor string, reason string) error
+	Cancel(ctx context.Context, username string, orderId string, reason string) error
+	Track(ctx context.Context, username string, orderId string) (entity.OrderStatus, *broker.Subscription, error)
+}
+
+type orderCase struct {
+	pool        *pgxpool.Pool
+	orepo       *repository.OrderRepo
+	cancelGrace time.Duration
+	events      broker.Broker
+}
+
+func NewOrderCase(pool *pgxpool.Pool, events broker.Broker) OrderCase {
+	return &orderCase{
+		pool:        pool,
+		orepo:       &repository.OrderRepo{},
+		cancelGrace: CancelGracePeriod(),
+		events:      events,
+	}
+}
+
+// CancelGracePeriod is how long after placing an order user still can cancel it once a merchant accepted it
+func CancelGracePeriod() time.Duration {
+	grace, err := time.ParseDuration(os.Getenv("ORDER_CANCEL_GRACE_PERIOD"))
+	if err != nil || grace < 0 {
+		return 2 * time.Minute
+	}
+
+	return grace
+}
+
+// Transition move order to the next status, only transition allowed by the state machine is accepted
+func (o *orderCase) Transition(ctx context.Context, orderId string, to entity.OrderStatus, actor string, reason string) error {
+	tx, err := o.pool.Begin(ctx)
+	if err != nil {
+		return exception.ServerError(err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	history, err := transitionOrderTx(ctx, tx, orderId, to, actor, reason)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return exception.ServerError(err.Error())
+	}
+
+	publishStatus(o.events, orderId, *history)
+
+	return nil
+}
+
+// Cancel order on behalf of its owner. Order can be cancelled before any merchant accept it,
+// or within the grace period as long as it is not picked up yet. Every merchant ticket is cancelled too.
+func (o *orderCase) Cancel(ctx context.Context, username string, orderId string, reason string) error {
+	if _, err := ulid.Parse(orderId); err != nil {
+		return exception.NotFound("orderId not found")
+	}
+
+	tx, err := o.pool.Begin(ctx)
+	if err != nil {
+		return exception.ServerError(err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	owner, status, createdAt, err := o.orepo.GetForUpdateTx(ctx, tx, orderId)
+	if err != nil || owner != username {
+		return exception.NotFound("orderId not found")
+	}
+
+	if status != entity.OrderPlaced && time.Since(createdAt) > o.cancelGrace {
+		return exception.Conflict("order can't be cancelled after cancellation window is over")
+	}
+
+	history, err := transitionOrderTx(ctx, tx, orderId, entity.OrderCancelled, username, reason)
+	if err != nil {
+		return err
+	}
+
+	if err := o.orepo.CancelTicketsTx(ctx, tx, orderId, reason); err != nil {
+		return exception.ServerError(err.Error())
+	}
+
+	if err := refundWalletTx(ctx, tx, orderId, username); err != nil {
+		return exception.ServerError(err.Error())
+	}
+
+	// release the courier, order can't be cancelled once it is picked up
+	courier, err := o.orepo.GetCourierTx(ctx, tx, orderId)
+	if err != nil {
+		return exception.ServerError(err.Error())
+	}
+
+	if courier != "" {
+		courierRepo := &repository.CourierRepo{}
+		if err := courierRepo.SetStatusTx(ctx, tx, courier, entity.CourierOnline); err != nil {
+			return exception.ServerError(err.Error())
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return exception.ServerError(err.Error())
+	}
+
+	publishStatus(o.events, orderId, *history)
+
+	return nil
+}
+
+// Track subscribe to events of the order owned by username, current status is returned to be sent first
+func (o *orderCase) Track(ctx context.Context, username string, orderId string) (entity.OrderStatus, *broker.Subscription, error) {
+	if _, err := ulid.Parse(orderId); err != nil {
+		return "", nil, exception.NotFound("orderId not found")
+	}
+
+	// subscribe before reading the status, so no change is missed in between
+	subscription := o.events.Subscribe(broker.OrderTopic(orderId))
+
+	status, err := o.orepo.GetStatusByOwner(ctx, o.pool, orderId, username)
+	if err != nil {
+		subscription.Close()
+		return "", nil, exception.NotFound("orderId not found")
+	}
+
+	return status, subscription, nil
+}
+
+// transitionOrderTx change order status and record it to status history, the order row is locked until tx end
+func transitionOrderTx(ctx context.Context, tx pgx.Tx, orderId string, to entity.OrderStatus, actor string, reason string) (*entity.OrderStatusHistory, error) {
+	orderRepo := &repository.OrderRepo{}
+
+	from, err := orderRepo.GetStatusForUpdateTx(ctx, tx, orderId)
+	if err != nil {
+		return nil, exception.NotFound("orderId not found")
+	}
+
+	if from.CanTransitionTo(to) == false {
+		return nil, exception.Conflict("order can't be " + string(to) + " when it is " + string(from))
+	}
+
+	if err := orderRepo.UpdateStatusTx(ctx, tx, orderId, to); err != nil {
+		return nil, exception.ServerError(err.Error())
+	}
+
+	history := &entity.OrderStatusHistory{
+		FromStatus: &from,
+		ToStatus:   to,
+		Actor:      actor,
+		Reason:     reason,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := orderRepo.InsertStatusHistoryTx(ctx, tx, orderId, history); err != nil {
+		return nil, exception.ServerError(err.Error())
+	}
+
+	return history, nil
+}
+
+// updateTicketTx move ticket of a merchant to the next status and keep order status in sync with its tickets
+func updateTicketTx(ctx context.Context, tx pgx.Tx, orderId string, merchantId string, to entity.TicketStatus, actor string, reason string) ([]entity.OrderStatusHistory, error) {
+	orderRepo := &repository.OrderRepo{}
+
+	// lock the order first, so tickets of the same order are updated one by one
+	if _, err := orderRepo.GetStatusForUpdateTx(ctx, tx, orderId); err != nil {
+		return nil, exception.NotFound("orderId not found")
+	}
+
+	from, err := orderRepo.GetTicketForUpdateTx(ctx, tx, orderId, merchantId)
+	if err != nil {
+		return nil, exception.NotFound("orderId not found")
+	}
+
+	if from.CanTransitionTo(to) == false {
+		return nil, exception.Conflict("order can't be " + string(to) + " when it is " + string(from))
+	}
+
+	if err := orderRepo.UpdateTicketTx(ctx, tx, orderId, merchantId, to, reason); err != nil {
+		return nil, exception.ServerError(err.Error())
+	}
+
+	return syncOrderStatusTx(ctx, tx, orderId, actor)
+}
+
+// syncOrderStatusTx derive order status from its tickets. The first accepted ticket accept the order,
+// the order is preparing once every merchant has decided, and it is rejected when every merchant reject it.
+func syncOrderStatusTx(ctx context.Context, tx pgx.Tx, orderId string, actor string) ([]entity.OrderStatusHistory, error) {
+	orderRepo := &repository.OrderRepo{}
+
+	status, err := orderRepo.GetStatusForUpdateTx(ctx, tx, orderId)
+	if err != nil {
+		return nil, exception.NotFound("orderId not found")
+	}
+
+	tickets, err := orderRepo.GetTicketStatusesTx(ctx, tx, orderId)
+	if err != nil {
+		return nil, exception.ServerError(err.Error())
+	}
+
+	var pending, accepted, rejected int
+	for _, ticket := range tickets {
+		switch ticket {
+		case entity.TicketPending:
+			pending++
+		case entity.TicketAccepted, entity.TicketReady:
+			accepted++
+		case entity.TicketRejected:
+			rejected++
+		}
+	}
+
+	changes := []entity.OrderStatusHistory{}
+
+	if status == entity.OrderPlaced && rejected == len(tickets) {
+		history, err := transitionOrderTx(ctx, tx, orderId, entity.OrderRejected, actor, "rejected by every merchant")
+		if err != nil {
+			return nil, err
+		}
+
+		if err := refundWalletTx(ctx, tx, orderId, actor); err != nil {
+			return nil, exception.ServerError(err.Error())
+		}
+
+		return append(changes, *history), nil
+	}
+
+	if status == entity.OrderPlaced && accepted > 0 {
+		history, err := transitionOrderTx(ctx, tx, orderId, entity.OrderAccepted, actor, "")
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, *history)
+		status = entity.OrderAccepted
+
+		if err := assignCourierTx(ctx, tx, orderId); err != nil {
+			return nil, exception.ServerError(err.Error())
+		}
+	}
+
+	if status == entity.OrderAccepted && accepted > 0 && pending == 0 {
+		history, err := transitionOrderTx(ctx, tx, orderId, entity.OrderPreparing, actor, "")
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, *history)
+	}
+
+	return changes, nil
+}
+
+// publishStatus notify subscribers of the order about committed status changes
+func publishStatus(events broker.Broker, orderId string, changes ...entity.OrderStatusHistory) {
+	for _, change := range changes {
+		events.Publish(broker.OrderTopic(orderId), broker.Event{
+			Type:      broker.EventStatus,
+			OrderId:   orderId,
+			Data:      change,
+			CreatedAt: change.CreatedAt,
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/pkg/payment"
+	"github.com/malikfajr/beli-mang/internal/repository"
+	"github.com/oklog/ulid/v2"
+)
+
+// paymentOrphanedAfter is how long a payment may wait for its order, order placing in progress is not disturbed before
+const paymentOrphanedAfter = 5 * time.Minute
+
+type PaymentCase interface {
+	Authorize(ctx context.Context, orderId string, username string, method string, amount int) (*entity.Payment, error)
+	Release(ctx context.Context, payment *entity.Payment)
+	HandleWebhook(ctx context.Context, header http.Header, body []byte) error
+	Settle(interval time.Duration)
+}
+
+type paymentCase struct {
+	pool     *pgxpool.Pool
+	provider payment.Provider
+	repo     *repository.PaymentRepo
+	orepo    *repository.OrderRepo
+}
+
+func NewPaymentCase(pool *pgxpool.Pool, provider payment.Provider) PaymentCase {
+	return &paymentCase{
+		pool:     pool,
+		provider: provider,
+		repo:     &repository.PaymentRepo{},
+		orepo:    &repository.OrderRepo{},
+	}
+}
+
+// Authorize hold the amount on the provider for an order about to be saved. The payment is saved as pending
+// before the provider is called, so an authorization whose order is never saved is released by Settle
+// even when this instance crash in between.
+func (p *paymentCase) Authorize(ctx context.Context, orderId string, username string, method string, amount int) (*entity.Payment, error) {
+	pending := &entity.Payment{
+		Id:       ulid.Make().String(),
+		OrderId:  orderId,
+		Username: username,
+		Provider: p.provider.Name(),
+		Method:   method,
+		Amount:   amount,
+		Status:   entity.PaymentPending,
+	}
+
+	if err := p.repo.Insert(ctx, p.pool, pending); err != nil {
+		log.Println("cannot save pending payment, because: ", err.Error())
+		return nil, exception.ServerError("failed to authorize payment, please try again")
+	}
+
+	result, err := p.authorize(ctx, pending)
+	if errors.Is(err, payment.ErrDeclined) {
+		pending.Status = entity.PaymentFailed
+		if err := p.repo.ResolvePending(ctx, p.pool, pending); err != nil {
+			log.Println("cannot save declined payment "+pending.Id+", because: ", err.Error())
+		}
+
+		return nil, exception.PaymentRequired("payment is declined")
+	}
+	if err != nil {
+		log.Println("cannot authorize payment, because: ", err.Error())
+		return nil, exception.ServerError("failed to authorize payment, please try again")
+	}
+
+	pending.Reference = result.Reference
+	pending.Status = entity.PaymentAuthorized
+	if err := p.repo.ResolvePending(ctx, p.pool, pending); err != nil {
+		log.Println("cannot save authorized payment "+pending.Id+", because: ", err.Error())
+		return nil, exception.ServerError("failed to authorize payment, please try again")
+	}
+
+	return pending, nil
+}
+
+func (p *paymentCase) authorize(ctx context.Context, current *entity.Payment) (*payment.Result, error) {
+	return p.provider.Authorize(ctx, &payment.AuthorizeRequest{
+		OrderId:  current.OrderId,
+		Username: current.Username,
+		Method:   current.Method,
+		Amount:   current.Amount,
+	})
+}
+
+// Release give back authorized amount of an order which failed to be saved, it is tried again by Settle when it fail
+func (p *paymentCase) Release(ctx context.Context, authorized *entity.Payment) {
+	if err := p.release(ctx, authorized.OrderId); err != nil {
+		log.Println("cannot release payment of order "+authorized.OrderId+", because: ", err.Error())
+	}
+}
+
+// HandleWebhook apply payment change reported by the provider, stale or repeated events are ignored
+func (p *paymentCase) HandleWebhook(ctx context.Context, header http.Header, body []byte) error {
+	event, err := p.provider.VerifyWebhook(header, body)
+	if err != nil {
+		return exception.Unauthorized("invalid webhook signature")
+	}
+
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return exception.ServerError(err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	current, err := p.repo.GetByReferenceForUpdateTx(ctx, tx, p.provider.Name(), event.Reference)
+	if err != nil {
+		return exception.NotFound("payment not found")
+	}
+
+	switch {
+	case event.Status == payment.StatusCaptured && current.Status == entity.PaymentAuthorized:
+		current.Status = entity.PaymentCaptured
+		current.CapturedAmount = event.Amount
+	case event.Status == payment.StatusRefunded && current.Status != entity.PaymentFailed && current.Status != entity.PaymentReleased:
+		current.Status = entity.PaymentRefunded
+		current.RefundedAmount = event.Amount
+	case event.Status == payment.StatusFailed && current.Status == entity.PaymentAuthorized:
+		current.Status = entity.PaymentFailed
+	default:
+		return nil
+	}
+
+	if err := p.repo.UpdateTx(ctx, tx, current); err != nil {
+		return exception.ServerError(err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return exception.ServerError(err.Error())
+	}
+
+	return nil
+}
+
+// Settle periodically capture payment of delivered orders, release payment of cancelled or rejected orders
+// and release authorization whose order was never saved
+func (p *paymentCase) Settle(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			<-ticker.C
+			ids, err := p.repo.GetUnsettled(context.Background(), p.pool, 20)
+			if err != nil {
+				log.Println("cannot get unsettled payment, because: ", err.Error())
+				continue
+			}
+
+			for _, id := range ids {
+				if err := p.settle(context.Background(), id); err != nil {
+					log.Println("cannot settle payment of order "+id+", because: ", err.Error())
+				}
+			}
+
+			orphans, err := p.repo.GetOrphaned(context.Background(), p.pool, paymentOrphanedAfter, 20)
+			if err != nil {
+				log.Println("cannot get orphaned payment, because: ", err.Error())
+				continue
+			}
+
+			for _, id := range orphans {
+				if err := p.release(context.Background(), id); err != nil {
+					log.Println("cannot release payment of order "+id+", because: ", err.Error())
+				}
+			}
+		}
+	}()
+}
+
+func (p *paymentCase) settle(ctx context.Context, orderId string) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	current, err := p.repo.GetForUpdateTx(ctx, tx, orderId)
+	if err != nil {
+		return err
+	}
+
+	// settled by webhook or another instance since it was listed
+	if current.Status != entity.PaymentAuthorized {
+		return nil
+	}
+
+	status, err := p.orepo.GetStatusForUpdateTx(ctx, tx, orderId)
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case entity.OrderDelivered:
+		// amount refunded before delivery is never captured
+		amount := current.Amount - current.RefundedAmount
+		if amount == 0 {
+			if _, err := p.provider.Refund(ctx, current.Reference, current.Amount, current.Id); err != nil {
+				return err
+			}
+			current.Status = entity.PaymentRefunded
+			break
+		}
+
+		result, err := p.provider.Capture(ctx, current.Reference, amount)
+		if err != nil {
+			return err
+		}
+		current.Status = entity.PaymentCaptured
+		current.CapturedAmount = result.Amount
+	case entity.OrderCancelled, entity.OrderRejected:
+		result, err := p.provider.Refund(ctx, current.Reference, current.Amount, current.Id)
+		if err != nil {
+			return err
+		}
+		current.Status = entity.PaymentRefunded
+		current.RefundedAmount = result.Amount
+	default:
+		return nil
+	}
+
+	if err := p.repo.UpdateTx(ctx, tx, current); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// release give back pending or authorized payment of an order which is not saved,
+// the payment row is locked so the order can't be saved with it at the same time
+func (p *paymentCase) release(ctx context.Context, orderId string) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	current, err := p.repo.GetForUpdateTx(ctx, tx, orderId)
+	if err != nil {
+		return err
+	}
+
+	if current.Status != entity.PaymentPending && current.Status != entity.PaymentAuthorized {
+		return nil
+	}
+
+	saved, err := p.orepo.ExistsTx(ctx, tx, orderId)
+	if err != nil {
+		return err
+	}
+
+	// settled with its order instead
+	if saved {
+		return nil
+	}
+
+	// the provider answer was lost, asking again return the same authorization if there was one
+	if current.Status == entity.PaymentPending {
+		result, err := p.authorize(ctx, current)
+		if errors.Is(err, payment.ErrDeclined) {
+			current.Status = entity.PaymentFailed
+			if err := p.repo.UpdateTx(ctx, tx, current); err != nil {
+				return err
+			}
+
+			return tx.Commit(ctx)
+		}
+		if err != nil {
+			return err
+		}
+
+		current.Reference = result.Reference
+	}
+
+	result, err := p.provider.Refund(ctx, current.Reference, current.Amount, current.Id)
+	if err != nil {
+		return err
+	}
+
+	current.Status = entity.PaymentReleased
+	current.RefundedAmount = result.Amount
+	if err := p.repo.UpdateTx(ctx, tx, current); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/pkg/payment"
+	"github.com/malikfajr/beli-mang/internal/repository"
+	"github.com/oklog/ulid/v2"
+)
+
+func TestWebhookRejectInvalidSignature(t *testing.T) {
+	// signature is verified before the database is touched
+	payments := NewPaymentCase(nil, payment.NewFakeProvider("secret"))
+
+	header := http.Header{}
+	header.Set(payment.FakeSignatureHeader, "00")
+
+	err := payments.HandleWebhook(context.Background(), header, []byte(`{"reference":"fake_1","status":"captured","amount":1}`))
+	if ex, ok := err.(*exception.CustomError); ok == false || ex.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got error %v, want unauthorized", err)
+	}
+}
+
+func TestDeclinedOrderIsNotSaved(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+
+	provider := payment.NewFakeProvider("")
+	pcase := NewPurchaseCase(pool, NewMemoryEstimateStore(), NewPricingCase(pool), NewPromotionCase(pool), NewPaymentCase(pool, provider))
+
+	seedUser(t, pool, "alice", false)
+	merchantId, productId := seedMerchant(t, pool, "merchantadmin", 15000)
+
+	estimateId := saveTestEstimate(t, pcase, "alice", merchantId, productId, 15000, 1)
+
+	_, err := pcase.PlaceOrder(ctx, "alice", &entity.PostOrderPayload{
+		CalculatedEstimateId: estimateId,
+		PaymentMethod:        payment.FakeMethodDeclined,
+	})
+	if ex, ok := err.(*exception.CustomError); ok == false || ex.StatusCode != http.StatusPaymentRequired {
+		t.Fatalf("got error %v, want payment required", err)
+	}
+
+	var orders int
+	if err := pool.QueryRow(ctx, "SELECT COUNT(*) FROM orders").Scan(&orders); err != nil {
+		t.Fatal(err)
+	}
+	if orders != 0 {
+		t.Errorf("%d orders saved, want 0", orders)
+	}
+
+	var status entity.PaymentStatus
+	if err := pool.QueryRow(ctx, "SELECT status FROM payments").Scan(&status); err != nil {
+		t.Fatal(err)
+	}
+	if status != entity.PaymentFailed {
+		t.Errorf("payment is %s, want %s", status, entity.PaymentFailed)
+	}
+
+	if _, err := pcase.GetEstimate(ctx, estimateId, "alice"); err != nil {
+		t.Errorf("estimate can't be ordered again: %v", err)
+	}
+}
+
+func TestOrphanedPaymentIsReleased(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+
+	payments := NewPaymentCase(pool, payment.NewFakeProvider("")).(*paymentCase)
+	repo := &repository.PaymentRepo{}
+
+	// authorized, then the order failed to be saved
+	authorized, err := payments.Authorize(ctx, ulid.Make().String(), "alice", "card", 30000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the instance crashed before the provider answer was saved
+	pending := &entity.Payment{
+		Id:       ulid.Make().String(),
+		OrderId:  ulid.Make().String(),
+		Username: "alice",
+		Provider: "fake",
+		Method:   "card",
+		Amount:   20000,
+		Status:   entity.PaymentPending,
+	}
+	if err := repo.Insert(ctx, pool, pending); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, orderId := range []string{authorized.OrderId, pending.OrderId} {
+		if err := payments.release(ctx, orderId); err != nil {
+			t.Fatal(err)
+		}
+
+		released, err := repo.GetByOrderId(ctx, pool, orderId)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if released.Status != entity.PaymentReleased || released.RefundedAmount != released.Amount || released.Reference != "fake_"+orderId {
+			t.Errorf("payment is %+v, want released in full", released)
+		}
+
+		// released twice, nothing change
+		if err := payments.release(ctx, orderId); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
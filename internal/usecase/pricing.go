@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/repository"
+)
+
+type PricingCase interface {
+	GetRules(ctx context.Context) []entity.PricingRule
+	SaveRule(ctx context.Context, rule *entity.PricingRule) error
+	DeleteRule(ctx context.Context, category string) error
+	Apply(ctx context.Context, estimate *entity.Estimate) error
+}
+
+type pricingCase struct {
+	pool              *pgxpool.Pool
+	repo              *repository.PricingRepo
+	serviceFeePercent float64
+	taxPercent        float64
+}
+
+// NewPricingCase read SERVICE_FEE_PERCENT and TAX_PERCENT, both are 0 by default
+func NewPricingCase(pool *pgxpool.Pool) PricingCase {
+	return &pricingCase{
+		pool:              pool,
+		repo:              &repository.PricingRepo{},
+		serviceFeePercent: envNumber("SERVICE_FEE_PERCENT"),
+		taxPercent:        envNumber("TAX_PERCENT"),
+	}
+}
+
+func (p *pricingCase) GetRules(ctx context.Context) []entity.PricingRule {
+	return p.repo.GetAll(ctx, p.pool)
+}
+
+func (p *pricingCase) SaveRule(ctx context.Context, rule *entity.PricingRule) error {
+	if err := p.repo.Upsert(ctx, p.pool, rule); err != nil {
+		return exception.ServerError(err.Error())
+	}
+
+	return nil
+}
+
+func (p *pricingCase) DeleteRule(ctx context.Context, category string) error {
+	if err := p.repo.Delete(ctx, p.pool, category); err != nil {
+		return exception.NotFound("pricing rule not found")
+	}
+
+	return nil
+}
+
+// Apply fill subtotal, fees, tax and total of the estimate from its items and distance,
+// using the rule of the starting point merchant category. Tax is charged on the discounted subtotal and the fees.
+func (p *pricingCase) Apply(ctx context.Context, estimate *entity.Estimate) error {
+	category := ""
+	subtotal := 0
+	for _, item := range estimate.Items {
+		subtotal += item.Price * item.Quantity
+		if item.MerchantId == estimate.StartingMerchantId {
+			category = item.MerchantCategory
+		}
+	}
+
+	rule := p.rule(ctx, category)
+
+	if subtotal < rule.MinimumOrder {
+		return exception.BadRequest("minimum order is " + strconv.Itoa(rule.MinimumOrder))
+	}
+
+	deliveryFee := rule.BaseFee + int(math.Round(float64(rule.PerKmFee)*estimate.DistanceKm))
+	deliveryFee += rule.ExtraMerchantFee * (len(estimate.Merchants()) - 1)
+	if rule.FreeDeliveryThreshold > 0 && subtotal >= rule.FreeDeliveryThreshold {
+		deliveryFee = 0
+	}
+
+	smallOrderFee := 0
+	if subtotal < rule.SmallOrderThreshold {
+		smallOrderFee = rule.SmallOrderFee
+	}
+
+	estimate.PricingCategory = rule.Category
+	estimate.Subtotal = subtotal
+	estimate.DeliveryFee = deliveryFee
+	estimate.SmallOrderFee = smallOrderFee
+	estimate.ServiceFee = percentOf(subtotal, p.serviceFeePercent)
+
+	if estimate.Discount > subtotal {
+		estimate.Discount = subtotal
+	}
+
+	taxable := subtotal - estimate.Discount + estimate.DeliveryFee + estimate.SmallOrderFee + estimate.ServiceFee
+	estimate.Tax = percentOf(taxable, p.taxPercent)
+	estimate.TotalPrice = taxable + estimate.Tax
+
+	return nil
+}
+
+// rule of the category, falling back to the default rule then to DELIVERY_BASE_FEE and DELIVERY_FEE_PER_KM env
+func (p *pricingCase) rule(ctx context.Context, category string) *entity.PricingRule {
+	if rule, err := p.repo.GetByCategory(ctx, p.pool, category); err == nil {
+		return rule
+	}
+
+	if rule, err := p.repo.GetByCategory(ctx, p.pool, entity.DefaultPricingCategory); err == nil {
+		return rule
+	}
+
+	return &entity.PricingRule{
+		Category: entity.DefaultPricingCategory,
+		BaseFee:  int(envNumber("DELIVERY_BASE_FEE")),
+		PerKmFee: int(envNumber("DELIVERY_FEE_PER_KM")),
+	}
+}
+
+func percentOf(amount int, percent float64) int {
+	return int(math.Round(float64(amount) * percent / 100))
+}
+
+func envNumber(key string) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil || value < 0 {
+		return 0
+	}
+
+	return value
+}
@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/repository"
+	"github.com/oklog/ulid/v2"
+)
+
+type PromotionCase interface {
+	Create(ctx context.Context, username string, promotion *entity.Promotion) error
+	GetAll(ctx context.Context, params *entity.PromotionParams) []entity.Promotion
+	Deactivate(ctx context.Context, code string) error
+	Apply(ctx context.Context, estimate *entity.Estimate) error
+}
+
+type promotionCase struct {
+	pool *pgxpool.Pool
+	repo *repository.PromotionRepo
+}
+
+func NewPromotionCase(pool *pgxpool.Pool) PromotionCase {
+	return &promotionCase{
+		pool: pool,
+		repo: &repository.PromotionRepo{},
+	}
+}
+
+func (p *promotionCase) Create(ctx context.Context, username string, promotion *entity.Promotion) error {
+	promotion.Code = strings.ToUpper(promotion.Code)
+
+	if promotion.DiscountType == entity.DiscountPercentage && promotion.DiscountValue > 100 {
+		return exception.BadRequest("percentage discount can't be more than 100")
+	}
+
+	if promotion.MerchantId != nil && promotion.MerchantCategory != nil {
+		return exception.BadRequest("promotion scope is either a merchant or a merchant category")
+	}
+
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && promotion.EndsAt.Before(*promotion.StartsAt) {
+		return exception.BadRequest("endsAt must be after startsAt")
+	}
+
+	if promotion.MerchantId != nil {
+		merchantRepo := &repository.MerchantRepo{}
+		if _, err := ulid.Parse(*promotion.MerchantId); err != nil {
+			return exception.NotFound("merchantId not found")
+		}
+
+		if _, err := merchantRepo.GetById(ctx, p.pool, *promotion.MerchantId); err != nil {
+			return exception.NotFound("merchantId not found")
+		}
+	}
+
+	if err := p.repo.Insert(ctx, p.pool, promotion, username); err != nil {
+		return exception.Conflict("promotion code already exists")
+	}
+
+	return nil
+}
+
+func (p *promotionCase) GetAll(ctx context.Context, params *entity.PromotionParams) []entity.Promotion {
+	if params.Limit <= 0 {
+		params.Limit = 5
+	}
+
+	if params.Offset < 0 {
+		params.Offset = 0
+	}
+
+	return p.repo.GetAll(ctx, p.pool, params)
+}
+
+// Deactivate stop the promotion, orders already using it keep their discount
+func (p *promotionCase) Deactivate(ctx context.Context, code string) error {
+	if err := p.repo.Deactivate(ctx, p.pool, strings.ToUpper(code)); err != nil {
+		return exception.NotFound("promotion not found")
+	}
+
+	return nil
+}
+
+// Apply set discount of the estimate from its promo code. Limits are checked again when the order is placed.
+func (p *promotionCase) Apply(ctx context.Context, estimate *entity.Estimate) error {
+	estimate.Discount = 0
+	if estimate.PromoCode == "" {
+		return nil
+	}
+
+	promotion, err := p.repo.GetByCode(ctx, p.pool, estimate.PromoCode)
+	if err != nil {
+		return exception.NotFound("promo code not found")
+	}
+
+	used := 0
+	if promotion.UsageLimitPerUser > 0 {
+		used = p.repo.CountRedemptions(ctx, p.pool, promotion.Code, estimate.Username)
+	}
+
+	discount, err := discountOf(promotion, estimate, used)
+	if err != nil {
+		return err
+	}
+
+	estimate.Discount = discount
+	return nil
+}
+
+// redeemPromotionTx record promo code of the estimate for the order, the promotion is locked so limits hold for concurrent orders
+func redeemPromotionTx(ctx context.Context, tx pgx.Tx, orderId string, estimate *entity.Estimate) error {
+	if estimate.PromoCode == "" {
+		return nil
+	}
+
+	promotionRepo := &repository.PromotionRepo{}
+
+	promotion, err := promotionRepo.GetForUpdateTx(ctx, tx, estimate.PromoCode)
+	if err != nil {
+		return exception.Conflict("promo code is no longer available")
+	}
+
+	used, err := promotionRepo.CountRedemptionsTx(ctx, tx, promotion.Code, estimate.Username)
+	if err != nil {
+		return err
+	}
+
+	discount, err := discountOf(promotion, estimate, used)
+	if err != nil {
+		return err
+	}
+
+	if discount != estimate.Discount {
+		return exception.Conflict("promo code has changed, please calculate the estimate again")
+	}
+
+	return promotionRepo.RedeemTx(ctx, tx, promotion.Code, estimate.Username, orderId, discount)
+}
+
+// discountOf check the promotion can be used by the estimate and return the discount.
+// Min spend and percentage are counted on items in scope of the promotion only.
+func discountOf(promotion *entity.Promotion, estimate *entity.Estimate, usedByUser int) (int, error) {
+	now := time.Now()
+
+	if promotion.Active == false || (promotion.StartsAt != nil && now.Before(*promotion.StartsAt)) || (promotion.EndsAt != nil && now.After(*promotion.EndsAt)) {
+		return 0, exception.BadRequest("promo code is not active")
+	}
+
+	if promotion.UsageLimit > 0 && promotion.UsedCount >= promotion.UsageLimit {
+		return 0, exception.Conflict("promo code has been fully redeemed")
+	}
+
+	if promotion.UsageLimitPerUser > 0 && usedByUser >= promotion.UsageLimitPerUser {
+		return 0, exception.Conflict("promo code usage limit reached")
+	}
+
+	eligible := 0
+	for _, item := range estimate.Items {
+		if promotion.MerchantId != nil && item.MerchantId != *promotion.MerchantId {
+			continue
+		}
+
+		if promotion.MerchantCategory != nil && item.MerchantCategory != *promotion.MerchantCategory {
+			continue
+		}
+
+		eligible += item.Price * item.Quantity
+	}
+
+	if eligible == 0 {
+		return 0, exception.BadRequest("promo code doesn't apply to any item")
+	}
+
+	if eligible < promotion.MinSpend {
+		return 0, exception.BadRequest("promo code need a minimum spend of " + strconv.Itoa(promotion.MinSpend))
+	}
+
+	discount := promotion.DiscountValue
+	if promotion.DiscountType == entity.DiscountPercentage {
+		discount = percentOf(eligible, float64(promotion.DiscountValue))
+		if promotion.MaxDiscount > 0 && discount > promotion.MaxDiscount {
+			discount = promotion.MaxDiscount
+		}
+	}
+
+	if discount > eligible {
+		discount = eligible
+	}
+
+	return discount, nil
+}
@@ -2,30 +2,54 @@ package usecase
 
 import (
 	"context"
+	"log"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/malikfajr/beli-mang/internal/entity"
 	"github.com/malikfajr/beli-mang/internal/entity/converter"
 	"github.com/malikfajr/beli-mang/internal/exception"
 	"github.com/malikfajr/beli-mang/internal/repository"
+	"github.com/oklog/ulid/v2"
 )
 
 type PurchaseCase interface {
 	GetMerchantNearby(ctx context.Context, params *converter.MerchanNearbyParams) (*[]converter.MerchanNearby, int, error)
 	GetHistory(ctx context.Context, params *entity.OrderHistoryParams) []entity.OrderHistory
+	GetOrder(ctx context.Context, username string, orderId string) (*entity.OrderHistory, error)
+	PriceEstimate(ctx context.Context, estimate *entity.Estimate) error
+	SaveEstimate(ctx context.Context, estimate *entity.Estimate) error
+	GetEstimate(ctx context.Context, estimateId string, username string) (*entity.Estimate, error)
+	PlaceOrder(ctx context.Context, username string, payload *entity.PostOrderPayload) (*entity.OrderResponse, error)
+	CleanExpiredEstimate(interval time.Duration)
 }
 
 type purchaseCase struct {
-	pool  *pgxpool.Pool
-	prepo *repository.PurchaseRepo
+	pool        *pgxpool.Pool
+	prepo       *repository.PurchaseRepo
+	orepo       *repository.OrderRepo
+	estimates   EstimateStore
+	estimateTTL time.Duration
+	pricing     PricingCase
+	promotions  PromotionCase
+	payments    PaymentCase
+	payrepo     *repository.PaymentRepo
 }
 
-func NewPurchaseCase(pool *pgxpool.Pool) PurchaseCase {
+func NewPurchaseCase(pool *pgxpool.Pool, estimates EstimateStore, pricing PricingCase, promotions PromotionCase, payments PaymentCase) PurchaseCase {
 	return &purchaseCase{
-		pool:  pool,
-		prepo: &repository.PurchaseRepo{},
+		pool:        pool,
+		prepo:       &repository.PurchaseRepo{},
+		orepo:       &repository.OrderRepo{},
+		estimates:   estimates,
+		estimateTTL: EstimateTTL(),
+		pricing:     pricing,
+		promotions:  promotions,
+		payments:    payments,
+		payrepo:     &repository.PaymentRepo{},
 	}
 }
 
@@ -45,6 +69,14 @@ func (p *purchaseCase) GetMerchantNearby(ctx context.Context, params *converter.
 		return nil, 0, exception.BadRequest("Coordinate not valid")
 	}
 
+	if params.MinRating < 0 || params.MinRating > 5 {
+		return nil, 0, exception.BadRequest("minRating must be between 0 and 5")
+	}
+
+	if params.SortBy != "" && params.SortBy != "distance" && params.SortBy != "rating" {
+		return nil, 0, exception.BadRequest("sortBy must be distance or rating")
+	}
+
 	if params.Limit == 0 {
 		params.Limit = 5
 	}
@@ -69,6 +101,203 @@ func (p *purchaseCase) GetHistory(ctx context.Context, params *entity.OrderHisto
 	return history
 }
 
+func (p *purchaseCase) GetOrder(ctx context.Context, username string, orderId string) (*entity.OrderHistory, error) {
+	if _, err := ulid.Parse(orderId); err != nil {
+		return nil, exception.NotFound("orderId not found")
+	}
+
+	history := p.prepo.GetHistory(ctx, p.pool, &entity.OrderHistoryParams{
+		Limit:    1,
+		OrderId:  orderId,
+		Username: username,
+	})
+
+	if len(history) == 0 {
+		return nil, exception.NotFound("orderId not found")
+	}
+
+	order := &history[0]
+	order.Charges = p.orepo.GetCharges(ctx, p.pool, orderId)
+	if payment, err := p.payrepo.GetByOrderId(ctx, p.pool, orderId); err == nil {
+		order.Payment = payment
+	}
+	order.Refunds = (&repository.RefundRepo{}).GetByOrderId(ctx, p.pool, orderId)
+	order.StatusHistory = p.orepo.GetStatusHistory(ctx, p.pool, orderId)
+
+	return order, nil
+}
+
+// PriceEstimate calculate discount, subtotal, fees and total price of the estimate
+func (p *purchaseCase) PriceEstimate(ctx context.Context, estimate *entity.Estimate) error {
+	if err := p.promotions.Apply(ctx, estimate); err != nil {
+		return err
+	}
+
+	return p.pricing.Apply(ctx, estimate)
+}
+
+func (p *purchaseCase) SaveEstimate(ctx context.Context, estimate *entity.Estimate) error {
+	estimate.ExpiresAt = time.Now().Add(p.estimateTTL)
+
+	if err := p.estimates.Save(ctx, estimate); err != nil {
+		return exception.ServerError(err.Error())
+	}
+
+	return nil
+}
+
+// GetEstimate return estimate of the user which can still be ordered
+func (p *purchaseCase) GetEstimate(ctx context.Context, estimateId string, username string) (*entity.Estimate, error) {
+	estimate, err := p.estimates.Get(ctx, estimateId, username)
+	if err != nil {
+		return nil, estimateError(err)
+	}
+
+	return estimate, nil
+}
+
+func estimateError(err error) error {
+	switch err {
+	case ErrEstimateNotFound:
+		return exception.NotFound("calculatedEstimateId is not found")
+	case ErrEstimateForbidden:
+		return exception.Forbidden("calculatedEstimateId belongs to another user")
+	case ErrEstimateExpired:
+		return exception.BadRequest("calculatedEstimateId is expired, please calculate a new estimate")
+	}
+
+	return exception.ServerError(err.Error())
+}
+
+// PlaceOrder turn estimate into order, order is only saved once its payment is authorized.
+// Authorization whose order fail to be saved is released.
+// The estimate is consumed in the same transaction as the order, its items and the payment,
+// so a failed order leave the estimate to be ordered again.
+func (p *purchaseCase) PlaceOrder(ctx context.Context, username string, payload *entity.PostOrderPayload) (*entity.OrderResponse, error) {
+	estimate, err := p.GetEstimate(ctx, payload.CalculatedEstimateId, username)
+	if err != nil {
+		return nil, err
+	}
+
+	orderId := ulid.Make().String()
+
+	// wallet is charged inside the order transaction, other methods are authorized by the provider first
+	var payment *entity.Payment
+	if payload.PaymentMethod != entity.PaymentMethodWallet {
+		payment, err = p.payments.Authorize(ctx, orderId, username, payload.PaymentMethod, estimate.TotalPrice)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.saveOrder(ctx, orderId, estimate.Id, username, payment); err != nil {
+		log.Println("cannot place order, because: ", err.Error())
+
+		if payment != nil {
+			p.payments.Release(context.Background(), payment)
+		}
+
+		if ex, ok := err.(*exception.CustomError); ok {
+			return nil, ex
+		}
+
+		return nil, exception.ServerError("failed to place order, please try again")
+	}
+
+	return &entity.OrderResponse{
+		OrderId: orderId,
+	}, nil
+}
+
+func (p *purchaseCase) saveOrder(ctx context.Context, orderId string, estimateId string, username string, payment *entity.Payment) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	estimate, err := p.estimates.ConsumeTx(ctx, tx, estimateId, username)
+	if err != nil {
+		return estimateError(err)
+	}
+
+	if err := p.prepo.InsertOrderTx(ctx, tx, orderId, estimate); err != nil {
+		return err
+	}
+
+	if err := p.prepo.InsertOrderItemsTx(ctx, tx, orderId, estimate.Items); err != nil {
+		return err
+	}
+
+	merchantIds := []string{}
+	for _, item := range estimate.Items {
+		if slices.Contains(merchantIds, item.MerchantId) == false {
+			merchantIds = append(merchantIds, item.MerchantId)
+		}
+	}
+
+	if err := p.orepo.InsertTicketsTx(ctx, tx, orderId, merchantIds); err != nil {
+		return err
+	}
+
+	if err := redeemPromotionTx(ctx, tx, orderId, estimate); err != nil {
+		return err
+	}
+
+	if payment == nil {
+		payment, err = chargeWalletTx(ctx, tx, estimate.Username, orderId, estimate.TotalPrice)
+		if err != nil {
+			return err
+		}
+
+		if err := p.payrepo.InsertTx(ctx, tx, payment); err != nil {
+			return err
+		}
+	} else {
+		// locked until the order is committed, so Settle can't release it meanwhile
+		authorized, err := p.payrepo.GetForUpdateTx(ctx, tx, orderId)
+		if err != nil {
+			return err
+		}
+
+		if authorized.Status != entity.PaymentAuthorized {
+			return exception.PaymentRequired("payment authorization is no longer valid, please order again")
+		}
+	}
+
+	placed := &entity.OrderStatusHistory{
+		ToStatus: entity.OrderPlaced,
+		Actor:    estimate.Username,
+	}
+
+	if err := p.orepo.InsertStatusHistoryTx(ctx, tx, orderId, placed); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// CleanExpiredEstimate periodically remove expired estimate from store
+func (p *purchaseCase) CleanExpiredEstimate(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			<-ticker.C
+			total, err := p.estimates.DeleteExpired(context.Background())
+			if err != nil {
+				log.Println("cannot clean expired estimate, because: ", err.Error())
+				continue
+			}
+
+			if total > 0 {
+				log.Printf("%d expired estimate removed", total)
+			}
+		}
+	}()
+}
+
 func (p *purchaseCase) validMerchantCategory(key string) bool {
 	categories := map[string]bool{
 		"SmallRestaurant":       true,
@@ -0,0 +1,283 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/pkg/payment"
+	"github.com/malikfajr/beli-mang/internal/repository"
+	"github.com/oklog/ulid/v2"
+)
+
+// refundPendingAfter is how long a refund may wait for the provider answer before it is sent again
+const refundPendingAfter = 5 * time.Minute
+
+type RefundCase interface {
+	Create(ctx context.Context, admin string, orderId string, payload *entity.RefundPayload) (*entity.Refund, error)
+	GetAll(ctx context.Context, orderId string) ([]entity.Refund, error)
+	RetryPending(interval time.Duration)
+}
+
+type refundCase struct {
+	pool     *pgxpool.Pool
+	provider payment.Provider
+	repo     *repository.RefundRepo
+	payrepo  *repository.PaymentRepo
+	orepo    *repository.OrderRepo
+}
+
+func NewRefundCase(pool *pgxpool.Pool, provider payment.Provider) RefundCase {
+	return &refundCase{
+		pool:     pool,
+		provider: provider,
+		repo:     &repository.RefundRepo{},
+		payrepo:  &repository.PaymentRepo{},
+		orepo:    &repository.OrderRepo{},
+	}
+}
+
+// Create refund part or all of what user paid for the order. Item refund is priced from the order snapshot
+// and capped by what is left to refund. Wallet payment is refunded to the wallet, captured payment through
+// the provider, and authorized payment simply capture less once the order is delivered.
+func (r *refundCase) Create(ctx context.Context, admin string, orderId string, payload *entity.RefundPayload) (*entity.Refund, error) {
+	if _, err := ulid.Parse(orderId); err != nil {
+		return nil, exception.NotFound("orderId not found")
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, exception.ServerError(err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	// lock the order, so refund doesn't race with cancellation
+	if _, _, _, err := r.orepo.GetForUpdateTx(ctx, tx, orderId); err != nil {
+		return nil, exception.NotFound("orderId not found")
+	}
+
+	paid, err := r.payrepo.GetForUpdateTx(ctx, tx, orderId)
+	if err != nil {
+		return nil, exception.NotFound("order has no payment to refund")
+	}
+
+	if paid.Status != entity.PaymentAuthorized && paid.Status != entity.PaymentCaptured {
+		return nil, exception.Conflict("payment can't be refunded when it is " + string(paid.Status))
+	}
+
+	remaining := paid.Amount - paid.RefundedAmount
+
+	refund := &entity.Refund{
+		Id:        ulid.Make().String(),
+		OrderId:   orderId,
+		Reason:    payload.Reason,
+		Items:     []entity.RefundItem{},
+		CreatedBy: admin,
+	}
+
+	if len(payload.Items) == 0 {
+		refund.Amount = remaining
+	} else {
+		items, err := r.refundItemsTx(ctx, tx, orderId, payload.Items)
+		if err != nil {
+			return nil, err
+		}
+
+		refund.Items = items
+		for _, item := range items {
+			refund.Amount += item.Amount
+		}
+
+		// discount and fees make the paid amount differ from the sum of item prices
+		refund.Amount = min(refund.Amount, remaining)
+	}
+
+	if refund.Amount <= 0 {
+		return nil, exception.Conflict("nothing left to refund")
+	}
+
+	refund.Destination = entity.RefundToPayment
+	if paid.Provider == entity.PaymentMethodWallet {
+		refund.Destination = entity.RefundToWallet
+	}
+
+	// money captured by provider is refunded after commit, the amount is reserved by the pending refund meanwhile
+	viaProvider := paid.Provider != entity.PaymentMethodWallet && paid.Status == entity.PaymentCaptured
+
+	refund.Status = entity.RefundCompleted
+	if viaProvider {
+		refund.Status = entity.RefundPending
+	}
+
+	if err := r.repo.InsertTx(ctx, tx, refund); err != nil {
+		return nil, exception.ServerError(err.Error())
+	}
+
+	paid.RefundedAmount += refund.Amount
+
+	if paid.Provider == entity.PaymentMethodWallet {
+		if err := creditRefundTx(ctx, tx, orderId, admin, refund.Amount, "refund of order "+orderId+": "+payload.Reason); err != nil {
+			return nil, exception.ServerError(err.Error())
+		}
+	}
+
+	if viaProvider == false && paid.Status == entity.PaymentCaptured && paid.RefundedAmount == paid.Amount {
+		paid.Status = entity.PaymentRefunded
+	}
+
+	if err := r.payrepo.UpdateTx(ctx, tx, paid); err != nil {
+		return nil, exception.ServerError(err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, exception.ServerError(err.Error())
+	}
+
+	if viaProvider {
+		return refund, r.refundViaProvider(ctx, refund, paid.Reference)
+	}
+
+	return refund, nil
+}
+
+// refundViaProvider ask the provider to refund a committed pending refund. A failed refund give the reserved
+// amount back so it can be retried. When the result can't be saved the refund stay pending and is sent again
+// by RetryPending, the provider refund it once because the refund id is its key.
+func (r *refundCase) refundViaProvider(ctx context.Context, refund *entity.Refund, reference string) error {
+	_, refundErr := r.provider.Refund(ctx, reference, refund.Amount, refund.Id)
+	if refundErr != nil {
+		log.Println("cannot refund payment "+reference+", because: ", refundErr.Error())
+		refund.Status = entity.RefundFailed
+	} else {
+		refund.Status = entity.RefundCompleted
+	}
+
+	if err := r.settleRefund(context.Background(), refund); err != nil {
+		log.Println("cannot save result of refund "+refund.Id+", it stay pending, because: ", err.Error())
+	}
+
+	if refundErr != nil {
+		return exception.ServerError("failed to refund payment, please try again")
+	}
+
+	return nil
+}
+
+func (r *refundCase) settleRefund(ctx context.Context, refund *entity.Refund) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	paid, err := r.payrepo.GetForUpdateTx(ctx, tx, refund.OrderId)
+	if err != nil {
+		return err
+	}
+
+	switch refund.Status {
+	case entity.RefundFailed:
+		paid.RefundedAmount -= refund.Amount
+	case entity.RefundCompleted:
+		if paid.Status == entity.PaymentCaptured && paid.RefundedAmount == paid.Amount {
+			paid.Status = entity.PaymentRefunded
+		}
+	}
+
+	settled, err := r.repo.SettlePendingTx(ctx, tx, refund.Id, refund.Status)
+	if err != nil {
+		return err
+	}
+
+	// settled by another request or instance meanwhile
+	if settled == false {
+		return nil
+	}
+
+	if err := r.payrepo.UpdateTx(ctx, tx, paid); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RetryPending periodically send again refunds whose provider answer was never saved
+func (r *refundCase) RetryPending(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			<-ticker.C
+			r.retryPending(context.Background(), refundPendingAfter)
+		}
+	}()
+}
+
+func (r *refundCase) retryPending(ctx context.Context, staleAfter time.Duration) {
+	refunds, err := r.repo.GetPending(ctx, r.pool, staleAfter, 20)
+	if err != nil {
+		log.Println("cannot get pending refund, because: ", err.Error())
+		return
+	}
+
+	for i := range refunds {
+		paid, err := r.payrepo.GetByOrderId(ctx, r.pool, refunds[i].OrderId)
+		if err != nil {
+			log.Println("cannot retry refund "+refunds[i].Id+", because: ", err.Error())
+			continue
+		}
+
+		// the result is logged and saved by refundViaProvider
+		r.refundViaProvider(ctx, &refunds[i], paid.Reference)
+	}
+}
+
+func (r *refundCase) GetAll(ctx context.Context, orderId string) ([]entity.Refund, error) {
+	if _, err := ulid.Parse(orderId); err != nil {
+		return nil, exception.NotFound("orderId not found")
+	}
+
+	return r.repo.GetByOrderId(ctx, r.pool, orderId), nil
+}
+
+// refundItemsTx price requested items from the order snapshot, quantity can't exceed what is not refunded yet
+func (r *refundCase) refundItemsTx(ctx context.Context, tx pgx.Tx, orderId string, requested []entity.RefundItemPayload) ([]entity.RefundItem, error) {
+	refundable, err := r.repo.GetRefundableItemsTx(ctx, tx, orderId)
+	if err != nil {
+		return nil, exception.ServerError(err.Error())
+	}
+
+	items := []entity.RefundItem{}
+	for _, req := range requested {
+		i := slices.IndexFunc(refundable, func(item entity.RefundItem) bool {
+			return item.MerchantId == req.MerchantId && item.ItemId == req.ItemId
+		})
+		if i == -1 {
+			return nil, exception.BadRequest("item " + req.ItemId + " is not in the order")
+		}
+
+		if req.Quantity > refundable[i].Quantity {
+			return nil, exception.Conflict("item " + req.ItemId + " can't be refunded more than ordered")
+		}
+
+		// the same item may be requested twice
+		refundable[i].Quantity -= req.Quantity
+
+		items = append(items, entity.RefundItem{
+			MerchantId:  req.MerchantId,
+			ItemId:      req.ItemId,
+			ProductName: refundable[i].ProductName,
+			UnitPrice:   refundable[i].UnitPrice,
+			Quantity:    req.Quantity,
+			Amount:      refundable[i].UnitPrice * req.Quantity,
+		})
+	}
+
+	return items, nil
+}
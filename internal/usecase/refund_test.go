@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/pkg/payment"
+	"github.com/malikfajr/beli-mang/internal/repository"
+	"github.com/oklog/ulid/v2"
+)
+
+func TestPendingRefundIsRetried(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+
+	provider := payment.NewFakeProvider("")
+	pcase := NewPurchaseCase(pool, NewMemoryEstimateStore(), NewPricingCase(pool), NewPromotionCase(pool), NewPaymentCase(pool, provider))
+	refunds := NewRefundCase(pool, provider).(*refundCase)
+
+	seedUser(t, pool, "alice", false)
+	merchantId, productId := seedMerchant(t, pool, "merchantadmin", 15000)
+
+	order, err := pcase.PlaceOrder(ctx, "alice", &entity.PostOrderPayload{
+		CalculatedEstimateId: saveTestEstimate(t, pcase, "alice", merchantId, productId, 15000, 2),
+		PaymentMethod:        "card",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// captured, then the instance crashed after the refund was committed and before the provider answer was saved
+	_, err = pool.Exec(ctx, "UPDATE payments SET status = $1, captured_amount = amount, refunded_amount = amount WHERE order_id = $2",
+		entity.PaymentCaptured, order.OrderId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pending := &entity.Refund{
+		Id:          ulid.Make().String(),
+		OrderId:     order.OrderId,
+		Amount:      30000,
+		Reason:      "lost answer",
+		Destination: entity.RefundToPayment,
+		Status:      entity.RefundPending,
+		CreatedBy:   "superadmin",
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (&repository.RefundRepo{}).InsertTx(ctx, tx, pending); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	refunds.retryPending(ctx, 0)
+
+	saved, err := refunds.GetAll(ctx, order.OrderId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || saved[0].Status != entity.RefundCompleted {
+		t.Fatalf("refunds are %+v, want one completed", saved)
+	}
+
+	paid, err := (&repository.PaymentRepo{}).GetByOrderId(ctx, pool, order.OrderId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paid.Status != entity.PaymentRefunded || paid.RefundedAmount != 30000 {
+		t.Errorf("payment is %+v, want refunded 30000", paid)
+	}
+
+	// already settled, retrying again change nothing
+	refunds.retryPending(ctx, 0)
+
+	paid, err = (&repository.PaymentRepo{}).GetByOrderId(ctx, pool, order.OrderId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paid.RefundedAmount != 30000 {
+		t.Errorf("refunded amount is %d after second retry, want 30000", paid.RefundedAmount)
+	}
+}
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/repository"
+	"github.com/oklog/ulid/v2"
+)
+
+type ReviewCase interface {
+	Create(ctx context.Context, username string, orderId string, payload *entity.ReviewPayload) (*entity.Review, error)
+}
+
+type reviewCase struct {
+	pool  *pgxpool.Pool
+	repo  *repository.ReviewRepo
+	orepo *repository.OrderRepo
+}
+
+func NewReviewCase(pool *pgxpool.Pool) ReviewCase {
+	return &reviewCase{
+		pool:  pool,
+		repo:  &repository.ReviewRepo{},
+		orepo: &repository.OrderRepo{},
+	}
+}
+
+// Create let the owner of a delivered order rate a merchant of the order once
+func (r *reviewCase) Create(ctx context.Context, username string, orderId string, payload *entity.ReviewPayload) (*entity.Review, error) {
+	if _, err := ulid.Parse(orderId); err != nil {
+		return nil, exception.NotFound("orderId not found")
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, exception.ServerError(err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	owner, status, _, err := r.orepo.GetForUpdateTx(ctx, tx, orderId)
+	if err != nil || owner != username {
+		return nil, exception.NotFound("orderId not found")
+	}
+
+	if status != entity.OrderDelivered {
+		return nil, exception.Conflict("order can only be reviewed after it is delivered")
+	}
+
+	if r.repo.MerchantServedTx(ctx, tx, orderId, payload.MerchantId) == false {
+		return nil, exception.NotFound("merchantId not found in the order")
+	}
+
+	now := time.Now()
+	review := &entity.Review{
+		Id:         ulid.Make().String(),
+		OrderId:    orderId,
+		MerchantId: payload.MerchantId,
+		Rating:     payload.Rating,
+		Comment:    payload.Comment,
+		CreatedAt:  &now,
+	}
+
+	inserted, err := r.repo.InsertTx(ctx, tx, username, review)
+	if err != nil {
+		return nil, exception.ServerError(err.Error())
+	}
+
+	if inserted == false {
+		return nil, exception.Conflict("merchant is already reviewed for this order")
+	}
+
+	if err := r.repo.AddMerchantRatingTx(ctx, tx, payload.MerchantId, payload.Rating); err != nil {
+		return nil, exception.ServerError(err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, exception.ServerError(err.Error())
+	}
+
+	return review, nil
+}
@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/malikfajr/beli-mang/internal/entity/converter"
+	"github.com/malikfajr/beli-mang/internal/repository"
+)
+
+func TestMerchantRatingDerivedFromSum(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo := &repository.ReviewRepo{}
+
+	merchantId, _ := seedMerchant(t, pool, "merchantadmin", 15000)
+
+	ratings := []int{3, 2, 5, 1, 3, 1, 1, 1, 5, 1}
+	sum := 0
+	for _, rating := range ratings {
+		tx, err := pool.Begin(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := repo.AddMerchantRatingTx(ctx, tx, merchantId, rating); err != nil {
+			tx.Rollback(ctx)
+			t.Fatal(err)
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		sum += rating
+	}
+
+	merchants := (&repository.PurchaseRepo{}).GetMerchantNearby(ctx, pool, -6.2, 106.8, &converter.MerchanNearbyParams{
+		MerchantId: merchantId,
+		Limit:      5,
+	})
+	if len(merchants) != 1 {
+		t.Fatalf("got %d merchants, want 1", len(merchants))
+	}
+
+	// 23 / 10 = 2.3, a running average rounded to 2 decimals on every review ends at 2.31
+	want := float64(sum) / float64(len(ratings))
+	if got := *merchants[0].Merchant.Rating; got != want {
+		t.Errorf("rating = %v, want %v", got, want)
+	}
+
+	if got := *merchants[0].Merchant.RatingCount; got != len(ratings) {
+		t.Errorf("rating count = %d, want %d", got, len(ratings))
+	}
+}
@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/pkg/token"
+	"github.com/malikfajr/beli-mang/internal/repository"
+	"github.com/oklog/ulid/v2"
+)
+
+type SessionCase interface {
+	Create(ctx context.Context, username string, role token.Role) (*entity.UserResponse, error)
+	Refresh(ctx context.Context, refreshToken string, roles ...token.Role) (*entity.UserResponse, error)
+	Revoke(ctx context.Context, sessionId string) error
+	IsRevoked(jti string) bool
+	SyncDenylist(interval time.Duration)
+}
+
+type sessionCase struct {
+	pool  *pgxpool.Pool
+	srepo *repository.SessionRepo
+
+	// denylist hold revoked session id, access token with this jti is rejected until it expired
+	denylist map[string]time.Time
+	sync.RWMutex
+}
+
+func NewSessionCase(pool *pgxpool.Pool) SessionCase {
+	return &sessionCase{
+		pool:     pool,
+		srepo:    &repository.SessionRepo{},
+		denylist: make(map[string]time.Time),
+	}
+}
+
+func (s *sessionCase) Create(ctx context.Context, username string, role token.Role) (*entity.UserResponse, error) {
+	sessionId := ulid.Make().String()
+	refreshToken, hash := token.CreateRefreshToken(sessionId)
+
+	session := &entity.Session{
+		Id:               sessionId,
+		Username:         username,
+		Role:             string(role),
+		RefreshTokenHash: hash,
+		ExpiresAt:        time.Now().Add(token.RefreshTokenTTL),
+	}
+
+	if err := s.srepo.Insert(ctx, s.pool, session); err != nil {
+		return nil, exception.ServerError(err.Error())
+	}
+
+	return &entity.UserResponse{
+		Token:        token.CreateToken(sessionId, username, role),
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+func (s *sessionCase) Refresh(ctx context.Context, refreshToken string, roles ...token.Role) (*entity.UserResponse, error) {
+	sessionId, hash, err := token.ParseRefreshToken(refreshToken)
+	if err != nil {
+		return nil, exception.Unauthorized("Invalid refresh token")
+	}
+
+	session, err := s.srepo.GetById(ctx, s.pool, sessionId)
+	if err != nil {
+		return nil, exception.Unauthorized("Invalid refresh token")
+	}
+
+	role := token.Role(session.Role)
+	if (&token.JwtClaim{Role: role}).HasRole(roles...) == false {
+		return nil, exception.Unauthorized("Invalid refresh token")
+	}
+
+	if session.RevokedAt != nil || session.ExpiresAt.Before(time.Now()) {
+		return nil, exception.Unauthorized("Session has been expired")
+	}
+
+	newRefreshToken, newHash := token.CreateRefreshToken(sessionId)
+
+	// refresh token that already rotated is used again, assume it was stolen and kill the session
+	if s.srepo.Rotate(ctx, s.pool, sessionId, hash, newHash, time.Now().Add(token.RefreshTokenTTL)) == false {
+		s.Revoke(ctx, sessionId)
+		return nil, exception.Unauthorized("Invalid refresh token")
+	}
+
+	return &entity.UserResponse{
+		Token:        token.CreateToken(sessionId, session.Username, role),
+		RefreshToken: newRefreshToken,
+	}, nil
+}
+
+func (s *sessionCase) Revoke(ctx context.Context, sessionId string) error {
+	if err := s.srepo.Revoke(ctx, s.pool, sessionId); err != nil {
+		return exception.ServerError(err.Error())
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	s.denylist[sessionId] = time.Now().Add(token.AccessTokenTTL)
+
+	return nil
+}
+
+func (s *sessionCase) IsRevoked(jti string) bool {
+	s.RLock()
+	defer s.RUnlock()
+
+	expiresAt, ok := s.denylist[jti]
+	return ok && expiresAt.After(time.Now())
+}
+
+// SyncDenylist periodically load sessions revoked by other replicas
+func (s *sessionCase) SyncDenylist(interval time.Duration) {
+	s.loadDenylist()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			<-ticker.C
+			s.loadDenylist()
+		}
+	}()
+}
+
+func (s *sessionCase) loadDenylist() {
+	// access token issued before revocation is valid at most AccessTokenTTL
+	ids, err := s.srepo.GetRevokedSince(context.Background(), s.pool, time.Now().Add(-token.AccessTokenTTL))
+	if err != nil {
+		log.Println("cannot load session denylist, because: ", err.Error())
+		return
+	}
+
+	denylist := make(map[string]time.Time, len(ids))
+	expiresAt := time.Now().Add(token.AccessTokenTTL)
+	for _, id := range ids {
+		denylist[id] = expiresAt
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	s.denylist = denylist
+}
@@ -0,0 +1,251 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/pkg/payment"
+	"github.com/malikfajr/beli-mang/internal/repository"
+	"github.com/oklog/ulid/v2"
+)
+
+type WalletCase interface {
+	Get(ctx context.Context, username string, params *entity.WalletParams) (*entity.Wallet, error)
+	TopUp(ctx context.Context, username string, payload *entity.TopUpPayload) (*entity.WalletTransaction, error)
+	Credit(ctx context.Context, admin string, username string, payload *entity.CreditPayload) (*entity.WalletTransaction, error)
+}
+
+type walletCase struct {
+	pool     *pgxpool.Pool
+	provider payment.Provider
+	repo     *repository.LedgerRepo
+	urepo    *repository.UserRepo
+}
+
+func NewWalletCase(pool *pgxpool.Pool, provider payment.Provider) WalletCase {
+	return &walletCase{
+		pool:     pool,
+		provider: provider,
+		repo:     &repository.LedgerRepo{},
+		urepo:    &repository.UserRepo{},
+	}
+}
+
+// Get return balance of user wallet with its transaction history
+func (w *walletCase) Get(ctx context.Context, username string, params *entity.WalletParams) (*entity.Wallet, error) {
+	if params.Limit == 0 {
+		params.Limit = 5
+	}
+
+	balance, err := w.repo.Balance(ctx, w.pool, entity.WalletAccount(username))
+	if err != nil {
+		return nil, exception.ServerError(err.Error())
+	}
+
+	return &entity.Wallet{
+		Balance:      balance,
+		Transactions: w.repo.GetAccountHistory(ctx, w.pool, entity.WalletAccount(username), params.Limit, params.Offset),
+	}, nil
+}
+
+// TopUp charge user through the payment provider and add the amount to the wallet
+func (w *walletCase) TopUp(ctx context.Context, username string, payload *entity.TopUpPayload) (*entity.WalletTransaction, error) {
+	id := ulid.Make().String()
+
+	result, err := w.provider.Authorize(ctx, &payment.AuthorizeRequest{
+		OrderId:  id,
+		Username: username,
+		Method:   payload.PaymentMethod,
+		Amount:   payload.Amount,
+	})
+	if errors.Is(err, payment.ErrDeclined) {
+		return nil, exception.PaymentRequired("payment is declined")
+	}
+	if err != nil {
+		log.Println("cannot authorize top up, because: ", err.Error())
+		return nil, exception.ServerError("failed to authorize payment, please try again")
+	}
+
+	// top up is not bound to any delivery, so it is captured right away
+	if _, err := w.provider.Capture(ctx, result.Reference, payload.Amount); err != nil {
+		log.Println("cannot capture top up, because: ", err.Error())
+		w.release(result.Reference, payload.Amount)
+		return nil, exception.ServerError("failed to capture payment, please try again")
+	}
+
+	transaction := &entity.LedgerTransaction{
+		Id:          id,
+		Type:        entity.LedgerTopUp,
+		Reference:   &result.Reference,
+		Description: "top up via " + w.provider.Name(),
+		CreatedBy:   username,
+		Entries: []entity.LedgerEntry{
+			{Account: entity.PaymentAccount(w.provider.Name()), Amount: -payload.Amount},
+			{Account: entity.WalletAccount(username), Amount: payload.Amount},
+		},
+	}
+
+	if err := w.save(ctx, transaction); err != nil {
+		log.Println("cannot save top up, because: ", err.Error())
+		w.release(result.Reference, payload.Amount)
+		return nil, exception.ServerError("failed to top up, please try again")
+	}
+
+	return walletTransaction(transaction, payload.Amount), nil
+}
+
+// Credit add money to user wallet on behalf of an admin, e.g. as compensation
+func (w *walletCase) Credit(ctx context.Context, admin string, username string, payload *entity.CreditPayload) (*entity.WalletTransaction, error) {
+	if _, err := w.urepo.GetByUsername(ctx, w.pool, username); err != nil {
+		return nil, exception.NotFound("user not found")
+	}
+
+	transaction := &entity.LedgerTransaction{
+		Id:          ulid.Make().String(),
+		Type:        entity.LedgerCredit,
+		Description: payload.Description,
+		CreatedBy:   admin,
+		Entries: []entity.LedgerEntry{
+			{Account: entity.AccountCredit, Amount: -payload.Amount},
+			{Account: entity.WalletAccount(username), Amount: payload.Amount},
+		},
+	}
+
+	if err := w.save(ctx, transaction); err != nil {
+		return nil, exception.ServerError(err.Error())
+	}
+
+	return walletTransaction(transaction, payload.Amount), nil
+}
+
+func (w *walletCase) save(ctx context.Context, transaction *entity.LedgerTransaction) error {
+	tx, err := w.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := w.repo.InsertTx(ctx, tx, transaction); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (w *walletCase) release(reference string, amount int) {
+	if _, err := w.provider.Refund(context.Background(), reference, amount, reference); err != nil {
+		log.Println("cannot release top up "+reference+", because: ", err.Error())
+	}
+}
+
+func walletTransaction(transaction *entity.LedgerTransaction, amount int) *entity.WalletTransaction {
+	return &entity.WalletTransaction{
+		Id:          transaction.Id,
+		Type:        transaction.Type,
+		Amount:      amount,
+		OrderId:     transaction.OrderId,
+		Description: transaction.Description,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// chargeWalletTx pay the order from user wallet. The wallet is locked until tx end,
+// so concurrent orders of the same user can't spend the same balance twice.
+func chargeWalletTx(ctx context.Context, tx pgx.Tx, username string, orderId string, amount int) (*entity.Payment, error) {
+	ledgerRepo := &repository.LedgerRepo{}
+	account := entity.WalletAccount(username)
+
+	if err := ledgerRepo.LockAccountTx(ctx, tx, account); err != nil {
+		return nil, err
+	}
+
+	balance, err := ledgerRepo.BalanceTx(ctx, tx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	if balance < amount {
+		return nil, exception.PaymentRequired("wallet balance is not enough")
+	}
+
+	transaction := &entity.LedgerTransaction{
+		Id:          ulid.Make().String(),
+		Type:        entity.LedgerOrder,
+		OrderId:     &orderId,
+		Description: "payment of order " + orderId,
+		CreatedBy:   username,
+		Entries: []entity.LedgerEntry{
+			{Account: account, Amount: -amount},
+			{Account: entity.AccountOrders, Amount: amount},
+		},
+	}
+
+	if err := ledgerRepo.InsertTx(ctx, tx, transaction); err != nil {
+		return nil, err
+	}
+
+	return &entity.Payment{
+		Id:             ulid.Make().String(),
+		OrderId:        orderId,
+		Username:       username,
+		Provider:       entity.PaymentMethodWallet,
+		Reference:      transaction.Id,
+		Method:         entity.PaymentMethodWallet,
+		Amount:         amount,
+		CapturedAmount: amount,
+		Status:         entity.PaymentCaptured,
+	}, nil
+}
+
+// refundWalletTx give the paid amount of a cancelled or rejected order back to the wallet,
+// orders not paid from wallet or already refunded are left untouched
+func refundWalletTx(ctx context.Context, tx pgx.Tx, orderId string, actor string) error {
+	paymentRepo := &repository.PaymentRepo{}
+
+	paid, err := paymentRepo.GetForUpdateTx(ctx, tx, orderId)
+	if err != nil || paid.Provider != entity.PaymentMethodWallet || paid.Status != entity.PaymentCaptured {
+		return nil
+	}
+
+	amount := paid.CapturedAmount - paid.RefundedAmount
+	if amount > 0 {
+		if err := creditRefundTx(ctx, tx, orderId, actor, amount, "refund of order "+orderId); err != nil {
+			return err
+		}
+	}
+
+	paid.Status = entity.PaymentRefunded
+	paid.RefundedAmount = paid.CapturedAmount
+
+	return paymentRepo.UpdateTx(ctx, tx, paid)
+}
+
+// creditRefundTx move amount paid for the order back to the wallet which was charged for it
+func creditRefundTx(ctx context.Context, tx pgx.Tx, orderId string, actor string, amount int, description string) error {
+	ledgerRepo := &repository.LedgerRepo{}
+
+	account, err := ledgerRepo.GetChargedAccountTx(ctx, tx, orderId)
+	if err != nil {
+		return err
+	}
+
+	transaction := &entity.LedgerTransaction{
+		Id:          ulid.Make().String(),
+		Type:        entity.LedgerRefund,
+		OrderId:     &orderId,
+		Description: description,
+		CreatedBy:   actor,
+		Entries: []entity.LedgerEntry{
+			{Account: entity.AccountOrders, Amount: -amount},
+			{Account: account, Amount: amount},
+		},
+	}
+
+	return ledgerRepo.InsertTx(ctx, tx, transaction)
+}
@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/malikfajr/beli-mang/internal/entity"
+	"github.com/malikfajr/beli-mang/internal/exception"
+	"github.com/malikfajr/beli-mang/internal/pkg/broker"
+	"github.com/malikfajr/beli-mang/internal/pkg/payment"
+	"github.com/oklog/ulid/v2"
+)
+
+// placeWalletOrder save an estimate of quantity product and order it paid from wallet
+func placeWalletOrder(t *testing.T, pool *pgxpool.Pool, pcase PurchaseCase, username string, merchantId string, productId string, price int, quantity int) (string, error) {
+	t.Helper()
+
+	estimateId := saveTestEstimate(t, pcase, username, merchantId, productId, price, quantity)
+
+	order, err := pcase.PlaceOrder(context.Background(), username, &entity.PostOrderPayload{
+		CalculatedEstimateId: estimateId,
+		PaymentMethod:        entity.PaymentMethodWallet,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return order.OrderId, nil
+}
+
+// saveTestEstimate save an estimate of quantity product, returning its id
+func saveTestEstimate(t *testing.T, pcase PurchaseCase, username string, merchantId string, productId string, price int, quantity int) string {
+	t.Helper()
+
+	estimate := &entity.Estimate{
+		Id:                 ulid.Make().String(),
+		Username:           username,
+		Subtotal:           price * quantity,
+		TotalPrice:         price * quantity,
+		PricingCategory:    entity.DefaultPricingCategory,
+		StartingMerchantId: merchantId,
+		UserLocation:       entity.Coordinate{Lat: -6.21, Long: 106.81},
+		Items: []entity.EstimateItem{{
+			MerchantId:       merchantId,
+			MerchantName:     "Warung Test",
+			MerchantCategory: "SmallRestaurant",
+			MerchantImageUrl: "https://example.com/m.jpg",
+			ProductId:        productId,
+			ProductName:      "Nasi Goreng",
+			ProductCategory:  "Food",
+			ProductImageUrl:  "https://example.com/p.jpg",
+			Price:            price,
+			Quantity:         quantity,
+		}},
+	}
+
+	if err := pcase.SaveEstimate(context.Background(), estimate); err != nil {
+		t.Fatal(err)
+	}
+
+	return estimate.Id
+}
+
+func walletBalance(t *testing.T, wallets WalletCase, username string) int {
+	t.Helper()
+
+	wallet, err := wallets.Get(context.Background(), username, &entity.WalletParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return wallet.Balance
+}
+
+func TestWalletRefundedOnCancel(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+
+	provider := payment.NewFakeProvider("")
+	wallets := NewWalletCase(pool, provider)
+	pcase := NewPurchaseCase(pool, NewMemoryEstimateStore(), NewPricingCase(pool), NewPromotionCase(pool), NewPaymentCase(pool, provider))
+	orders := NewOrderCase(pool, broker.New())
+
+	seedUser(t, pool, "alice", false)
+	merchantId, productId := seedMerchant(t, pool, "merchantadmin", 15000)
+
+	if _, err := wallets.Credit(ctx, "superadmin", "alice", &entity.CreditPayload{Amount: 50000, Description: "welcome"}); err != nil {
+		t.Fatal(err)
+	}
+
+	orderId, err := placeWalletOrder(t, pool, pcase, "alice", merchantId, productId, 15000, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if balance := walletBalance(t, wallets, "alice"); balance != 20000 {
+		t.Fatalf("balance after order = %d, want 20000", balance)
+	}
+
+	if err := orders.Cancel(ctx, "alice", orderId, "changed my mind"); err != nil {
+		t.Fatal(err)
+	}
+
+	if balance := walletBalance(t, wallets, "alice"); balance != 50000 {
+		t.Fatalf("balance after cancel = %d, want 50000", balance)
+	}
+
+	// every entry of a wallet must land on the same account
+	var accounts int
+	if err := pool.QueryRow(ctx, "SELECT COUNT(DISTINCT account) FROM ledger_entries WHERE account LIKE 'wallet:%'").Scan(&accounts); err != nil {
+		t.Fatal(err)
+	}
+	if accounts != 1 {
+		t.Errorf("ledger has %d wallet accounts, want 1", accounts)
+	}
+
+	var unbalanced int
+	if err := pool.QueryRow(ctx, "SELECT COUNT(*) FROM (SELECT transaction_id FROM ledger_entries GROUP BY transaction_id HAVING SUM(amount) <> 0) t").Scan(&unbalanced); err != nil {
+		t.Fatal(err)
+	}
+	if unbalanced != 0 {
+		t.Errorf("%d ledger transactions are not balanced", unbalanced)
+	}
+
+	paid, err := pcase.GetOrder(ctx, "alice", orderId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paid.Payment == nil || paid.Payment.Status != entity.PaymentRefunded {
+		t.Errorf("payment is %+v, want refunded", paid.Payment)
+	}
+}
+
+func TestWalletOrderRejectedWhenBalanceNotEnough(t *testing.T) {
+	pool := testPool(t)
+
+	provider := payment.NewFakeProvider("")
+	wallets := NewWalletCase(pool, provider)
+	pcase := NewPurchaseCase(pool, NewMemoryEstimateStore(), NewPricingCase(pool), NewPromotionCase(pool), NewPaymentCase(pool, provider))
+
+	seedUser(t, pool, "bob", false)
+	merchantId, productId := seedMerchant(t, pool, "merchantadmin", 15000)
+
+	if _, err := wallets.Credit(context.Background(), "superadmin", "bob", &entity.CreditPayload{Amount: 10000, Description: "welcome"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := placeWalletOrder(t, pool, pcase, "bob", merchantId, productId, 15000, 1)
+	if ex, ok := err.(*exception.CustomError); ok == false || ex.StatusCode != 402 {
+		t.Fatalf("got error %v, want payment required", err)
+	}
+
+	if balance := walletBalance(t, wallets, "bob"); balance != 10000 {
+		t.Fatalf("balance = %d, want 10000", balance)
+	}
+}